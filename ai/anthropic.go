@@ -0,0 +1,318 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	anthropicDefaultURL       = "https://api.anthropic.com"
+	anthropicDefaultVersion   = "2023-06-01"
+	anthropicDefaultMaxTokens = 1024
+)
+
+type AnthropicProvider struct {
+	client  *http.Client
+	url     string
+	key     string
+	version string
+}
+
+type anthropicMessage struct {
+	Role    string             `json:"role"`
+	Content []anthropicContent `json:"content"`
+}
+
+type anthropicContent struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type string `json:"type"`
+}
+
+type anthropicRequest struct {
+	Model         string               `json:"model"`
+	MaxTokens     int                  `json:"max_tokens"`
+	System        string               `json:"system,omitempty"`
+	Messages      []anthropicMessage   `json:"messages"`
+	Tools         []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice    *anthropicToolChoice `json:"tool_choice,omitempty"`
+	Stream        bool                 `json:"stream,omitempty"`
+	Temperature   *float64             `json:"temperature,omitempty"`
+	TopP          *float64             `json:"top_p,omitempty"`
+	StopSequences []string             `json:"stop_sequences,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []anthropicContent `json:"content"`
+	Usage   struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type anthropicModelsResponse struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+}
+
+func NewAnthropicProvider(key, baseURL, version string, httpClient *http.Client) *AnthropicProvider {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = anthropicDefaultURL
+	}
+	if version == "" {
+		version = anthropicDefaultVersion
+	}
+
+	return &AnthropicProvider{
+		client:  httpClient,
+		url:     strings.TrimRight(baseURL, "/"),
+		key:     key,
+		version: version,
+	}
+}
+
+func (p *AnthropicProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	resp, err := doJSON(ctx, p.client, http.MethodPost, p.url+"/v1/messages", p.prepareHeaders(), p.prepareRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var message anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	var toolCalls []ToolCall
+	for _, block := range message.Content {
+		switch block.Type {
+		case "text":
+			sb.WriteString(block.Text)
+		case "tool_use":
+			toolCalls = append(toolCalls, ToolCall{
+				ID:        block.ID,
+				Name:      block.Name,
+				Arguments: string(block.Input),
+			})
+		}
+	}
+
+	return &Response{
+		Content:   sb.String(),
+		ToolCalls: toolCalls,
+		Usage: Usage{
+			PromptTokens:     message.Usage.InputTokens,
+			CompletionTokens: message.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+func (p *AnthropicProvider) Stream(ctx context.Context, req Request) (Stream, error) {
+	resp, err := doJSON(ctx, p.client, http.MethodPost, p.url+"/v1/messages", p.prepareHeaders(), p.prepareRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	return &anthropicStream{
+		body:    resp.Body,
+		scanner: scanner,
+	}, nil
+}
+
+func (p *AnthropicProvider) ListModels(ctx context.Context) ([]string, error) {
+	resp, err := doJSON(ctx, p.client, http.MethodGet, p.url+"/v1/models", p.prepareHeaders(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var list anthropicModelsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(list.Data))
+	for _, model := range list.Data {
+		models = append(models, model.ID)
+	}
+
+	return models, nil
+}
+
+func (p *AnthropicProvider) prepareHeaders() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.key,
+		"anthropic-version": p.version,
+	}
+}
+
+// prepareRequest lifts system messages to the top level field, sends tool
+// results as user tool_result blocks and merges consecutive messages of the
+// same role, as required by the Messages API.
+// Seed, penalties and response formats are not supported by Anthropic and are dropped.
+func (p *AnthropicProvider) prepareRequest(req Request, stream bool) anthropicRequest {
+	var system []string
+	messages := make([]anthropicMessage, 0, len(req.Messages))
+
+	for _, message := range req.Messages {
+		if message.Role == RoleSystem {
+			system = append(system, message.Content)
+			continue
+		}
+
+		role := message.Role
+		var content []anthropicContent
+
+		switch message.Role {
+		case RoleTool:
+			role = RoleUser
+			content = append(content, anthropicContent{
+				Type:      "tool_result",
+				ToolUseID: message.ToolCallID,
+				Content:   message.Content,
+			})
+		default:
+			if message.Content != "" {
+				content = append(content, anthropicContent{
+					Type: "text",
+					Text: message.Content,
+				})
+			}
+			for _, call := range message.ToolCalls {
+				input := call.Arguments
+				if input == "" {
+					input = "{}"
+				}
+
+				content = append(content, anthropicContent{
+					Type:  "tool_use",
+					ID:    call.ID,
+					Name:  call.Name,
+					Input: json.RawMessage(input),
+				})
+			}
+		}
+
+		if len(messages) > 0 && messages[len(messages)-1].Role == role {
+			messages[len(messages)-1].Content = append(messages[len(messages)-1].Content, content...)
+			continue
+		}
+
+		messages = append(messages, anthropicMessage{
+			Role:    role,
+			Content: content,
+		})
+	}
+
+	tools := make([]anthropicTool, 0, len(req.Tools))
+	for _, tool := range req.Tools {
+		tools = append(tools, anthropicTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: tool.Parameters,
+		})
+	}
+
+	var toolChoice *anthropicToolChoice
+	if len(tools) > 0 && req.NoToolCalls {
+		toolChoice = &anthropicToolChoice{Type: "none"}
+	}
+
+	maxTokens := req.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = anthropicDefaultMaxTokens
+	}
+
+	return anthropicRequest{
+		Model:         req.Model,
+		MaxTokens:     maxTokens,
+		System:        strings.Join(system, "\n\n"),
+		Messages:      messages,
+		Tools:         tools,
+		ToolChoice:    toolChoice,
+		Stream:        stream,
+		Temperature:   req.Sampling.Temperature,
+		TopP:          req.Sampling.TopP,
+		StopSequences: req.Sampling.Stop,
+	}
+}
+
+type anthropicStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	done    bool
+}
+
+func (s *anthropicStream) Recv() (string, error) {
+	for !s.done && s.scanner.Scan() {
+		line := s.scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			return "", err
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				return event.Delta.Text, nil
+			}
+		case "message_stop":
+			s.done = true
+		case "error":
+			return "", errors.New(event.Error.Message)
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", io.EOF
+}
+
+func (s *anthropicStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,180 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnthropicProvider(t *testing.T) {
+	t.Run("Complete", testAnthropicComplete)
+	t.Run("Tools", testAnthropicTools)
+	t.Run("NoToolCalls", testAnthropicNoToolCalls)
+	t.Run("Stream", testAnthropicStream)
+	t.Run("StreamError", testAnthropicStreamError)
+	t.Run("ListModels", testAnthropicListModels)
+}
+
+func testAnthropicComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "test_key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicDefaultVersion, r.Header.Get("anthropic-version"))
+
+		var body anthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "claude", body.Model)
+		assert.Equal(t, anthropicDefaultMaxTokens, body.MaxTokens)
+		assert.Equal(t, "system", body.System)
+		assert.Equal(t, []anthropicMessage{{
+			Role: RoleUser,
+			Content: []anthropicContent{
+				{Type: "text", Text: "pipe"},
+				{Type: "text", Text: "ping"},
+			},
+		}}, body.Messages)
+
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"po"},{"type":"text","text":"ng"}],"usage":{"input_tokens":30,"output_tokens":5}}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test_key", server.URL, "", http.DefaultClient)
+
+	resp, err := provider.Complete(context.Background(), Request{
+		Model: "claude",
+		Messages: []Message{
+			{Role: RoleSystem, Content: "system"},
+			{Role: RoleUser, Content: "pipe"},
+			{Role: RoleUser, Content: "ping"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "pong", resp.Content)
+	assert.Equal(t, Usage{PromptTokens: 30, CompletionTokens: 5}, resp.Usage)
+}
+
+func testAnthropicTools(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body anthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Len(t, body.Tools, 1)
+		assert.Equal(t, WhichTool, body.Tools[0].Name)
+		assert.Nil(t, body.ToolChoice)
+
+		require.Len(t, body.Messages, 3)
+		assert.Equal(t, RoleAssistant, body.Messages[1].Role)
+		assert.Equal(t, "tool_use", body.Messages[1].Content[0].Type)
+		assert.Equal(t, RoleUser, body.Messages[2].Role)
+		assert.Equal(t, anthropicContent{Type: "tool_result", ToolUseID: "toolu_1", Content: "/usr/bin/git"}, body.Messages[2].Content[0])
+
+		fmt.Fprint(w, `{"content":[{"type":"tool_use","id":"toolu_2","name":"which","input":{"binary":"kubectl"}}]}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test_key", server.URL, "", http.DefaultClient)
+
+	resp, err := provider.Complete(context.Background(), Request{
+		Model: "claude",
+		Messages: []Message{
+			{Role: RoleUser, Content: "ping"},
+			{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "toolu_1", Name: WhichTool, Arguments: `{"binary":"git"}`}}},
+			{Role: RoleTool, Content: "/usr/bin/git", ToolCallID: "toolu_1"},
+		},
+		Tools: NewToolbox([]string{WhichTool}).GetDefinitions(),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []ToolCall{{ID: "toolu_2", Name: WhichTool, Arguments: `{"binary":"kubectl"}`}}, resp.ToolCalls)
+}
+
+func testAnthropicStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body anthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.True(t, body.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\"}\n\n")
+		fmt.Fprint(w, "event: ping\ndata: {\"type\":\"ping\"}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"po\"}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"ng\"}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test_key", server.URL, "", http.DefaultClient)
+
+	stream, err := provider.Stream(context.Background(), Request{
+		Model:    "claude",
+		Messages: []Message{{Role: RoleUser, Content: "ping"}},
+	})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var output string
+	for {
+		delta, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		output += delta
+	}
+
+	assert.Equal(t, "pong", output)
+}
+
+func testAnthropicStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test_key", server.URL, "", http.DefaultClient)
+
+	stream, err := provider.Stream(context.Background(), Request{Model: "claude"})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	assert.EqualError(t, err, "Overloaded")
+}
+
+func testAnthropicListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+
+		fmt.Fprint(w, `{"data":[{"id":"claude-a"},{"id":"claude-b"}]}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider("test_key", server.URL, "", http.DefaultClient)
+
+	models, err := provider.ListModels(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"claude-a", "claude-b"}, models)
+}
+
+func testAnthropicNoToolCalls(t *testing.T) {
+	provider := NewAnthropicProvider("test_key", "", "", http.DefaultClient)
+
+	body := provider.prepareRequest(Request{
+		Messages:    []Message{{Role: RoleUser, Content: "ping"}},
+		Tools:       NewToolbox([]string{WhichTool}).GetDefinitions(),
+		NoToolCalls: true,
+	}, false)
+
+	require.Len(t, body.Tools, 1)
+	assert.Equal(t, &anthropicToolChoice{Type: "none"}, body.ToolChoice)
+}
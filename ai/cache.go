@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Cache stores exec answers on disk, one file per key, so repeated questions
+// are answered without calling the model
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+type cacheEntry struct {
+	Created time.Time        `json:"created"`
+	Output  EngineExecOutput `json:"output"`
+}
+
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{
+		dir: dir,
+		ttl: ttl,
+	}
+}
+
+// Get returns the answer cached under key, expired answers are removed
+func (c *Cache) Get(key string) (*EngineExecOutput, bool) {
+	content, err := os.ReadFile(c.getPath(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, false
+	}
+
+	if c.ttl > 0 && time.Since(entry.Created) > c.ttl {
+		os.Remove(c.getPath(key))
+		return nil, false
+	}
+
+	return &entry.Output, true
+}
+
+func (c *Cache) Put(key string, output EngineExecOutput) error {
+	content, err := json.Marshal(cacheEntry{
+		Created: time.Now(),
+		Output:  output,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+
+	tmp := c.getPath(key) + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, c.getPath(key))
+}
+
+// Clear removes every cached answer
+func (c *Cache) Clear() error {
+	err := os.RemoveAll(c.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (c *Cache) getPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// getCacheKey hashes everything the answer depends on: the mode, the model,
+// the normalized prompt, the system prompt holding the facts and preferences,
+// the piped input and the previous messages of the conversation
+func (e *Engine) getCacheKey(input string) string {
+	hash := sha256.New()
+
+	parts := []string{
+		e.mode.String(),
+		e.config.GetAIConfig().GetProvider(),
+		e.config.GetAIConfig().GetModel(),
+		normalizePrompt(input),
+		e.prepareSystemPrompt(),
+		e.pipe,
+	}
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	for _, message := range e.getMessages() {
+		hash.Write([]byte(message.Role))
+		hash.Write([]byte{0})
+		hash.Write([]byte(message.Content))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// normalizePrompt ignores extra spaces and trailing punctuation, so "list
+// listening ports?" and "list  listening ports" share an answer, case is kept
+// since it matters in names and paths, eg. find README or find readme
+func normalizePrompt(input string) string {
+	normalized := strings.Join(strings.Fields(input), " ")
+
+	return strings.TrimRight(normalized, "?!. ")
+}
@@ -0,0 +1,84 @@
+package ai
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	t.Run("NormalizePrompt", testNormalizePrompt)
+	t.Run("GetPut", testCacheGetPut)
+	t.Run("Expired", testCacheExpired)
+	t.Run("ExecCompletion", testCacheExecCompletion)
+}
+
+func testNormalizePrompt(t *testing.T) {
+	assert.Equal(t, "list listening ports", normalizePrompt("  list   listening ports ?"))
+	assert.Equal(t, normalizePrompt("list listening ports."), normalizePrompt("list listening ports"))
+	assert.NotEqual(t, normalizePrompt("find README"), normalizePrompt("find readme"))
+}
+
+func testCacheGetPut(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"), time.Hour)
+
+	_, ok := cache.Get("key")
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Put("key", EngineExecOutput{Command: "ss -tlnp", Explanation: "list listening ports", Executable: true}))
+
+	output, ok := cache.Get("key")
+	require.True(t, ok)
+	assert.Equal(t, "ss -tlnp", output.GetCommand())
+	assert.Equal(t, "list listening ports", output.GetExplanation())
+	assert.True(t, output.IsExecutable())
+
+	require.NoError(t, cache.Clear())
+	_, ok = cache.Get("key")
+	assert.False(t, ok)
+}
+
+func testCacheExpired(t *testing.T) {
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"), time.Nanosecond)
+
+	require.NoError(t, cache.Put("key", EngineExecOutput{Command: "ls"}))
+	time.Sleep(time.Millisecond)
+
+	_, ok := cache.Get("key")
+	assert.False(t, ok)
+}
+
+func testCacheExecCompletion(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\ntools:\n  allowed: []\n")
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"), time.Hour)
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: `{"cmd":"ss -tlnp", "exp": "list listening ports", "exec": true}`},
+			{Content: `{"cmd":"df -h", "exp": "show disk usage", "exec": true}`},
+		},
+	}
+
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, provider).SetCache(cache)
+	output, err := engine.ExecCompletion(context.Background(), "list listening ports")
+	require.NoError(t, err)
+	assert.False(t, output.IsCached())
+
+	engine = NewEngineWithProvider(ExecEngineMode, cfg, provider).SetCache(cache)
+	output, err = engine.ExecCompletion(context.Background(), "list listening ports?")
+	require.NoError(t, err)
+	assert.True(t, output.IsCached())
+	assert.Equal(t, "ss -tlnp", output.GetCommand())
+	assert.Len(t, provider.requests, 1)
+	assert.Len(t, engine.execMessages, 2)
+
+	// the same question later in a conversation depends on the previous turns
+	output, err = engine.ExecCompletion(context.Background(), "list listening ports")
+	require.NoError(t, err)
+	assert.False(t, output.IsCached())
+	assert.Len(t, provider.requests, 2)
+}
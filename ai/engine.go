@@ -6,14 +6,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/url"
-	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/bmichalkiewicz/gogut/config"
 	"github.com/bmichalkiewicz/gogut/facts"
-
-	"github.com/sashabaranov/go-openai"
+	"github.com/bmichalkiewicz/gogut/run"
 )
 
 const noexec = "[noexec]"
@@ -21,42 +19,50 @@ const noexec = "[noexec]"
 type Engine struct {
 	mode         EngineMode
 	config       *config.Config
-	client       *openai.Client
-	execMessages []openai.ChatCompletionMessage
-	chatMessages []openai.ChatCompletionMessage
+	backends     []Backend
+	backend      int
+	retry        RetryPolicy
+	toolbox      *Toolbox
+	cache        *Cache
+	execMessages []Message
+	chatMessages []Message
+	summary      string
 	channel      chan EngineChatStreamOutput
 	pipe         string
-	running      bool
+	sampling     Sampling
+	fix          bool
+	cancel       context.CancelFunc
+	mutex        sync.Mutex
 }
 
 func NewEngine(mode EngineMode, config *config.Config) (*Engine, error) {
-	var client *openai.Client
-
-	if config.GetAIConfig().GetURL() == "" {
-		client = openai.NewClient(config.GetAIConfig().GetKey())
-	} else {
-		clientConfig := openai.DefaultConfig(config.GetAIConfig().GetKey())
+	backends, err := NewBackends(config.GetAIConfig())
+	if err != nil {
+		return nil, err
+	}
 
-		url, err := url.Parse(config.GetAIConfig().GetURL())
-		if err != nil {
-			return nil, err
-		}
+	return NewEngineWithBackends(mode, config, backends), nil
+}
 
-		clientConfig.BaseURL = url.Scheme + "://" + url.Host + "/v1"
+func NewEngineWithProvider(mode EngineMode, config *config.Config, provider Provider) *Engine {
+	backend := NewBackend(getBackendName(config.GetAIConfig()), config.GetAIConfig().GetModel(), provider)
+	backend.window = getConfigContextWindow(config.GetAIConfig())
 
-		client = openai.NewClientWithConfig(clientConfig)
-	}
+	return NewEngineWithBackends(mode, config, []Backend{backend})
+}
 
+func NewEngineWithBackends(mode EngineMode, config *config.Config, backends []Backend) *Engine {
 	return &Engine{
 		mode:         mode,
 		config:       config,
-		client:       client,
-		execMessages: make([]openai.ChatCompletionMessage, 0),
-		chatMessages: make([]openai.ChatCompletionMessage, 0),
+		backends:     backends,
+		retry:        NewRetryPolicy(config.GetAIConfig().GetRetries(), config.GetAIConfig().GetRetryBackoff()),
+		toolbox:      NewToolbox(config.GetToolsConfig().GetAllowed()).SetHelpBinaries(config.GetToolsConfig().GetHelpBinaries()),
+		execMessages: make([]Message, 0),
+		chatMessages: make([]Message, 0),
 		channel:      make(chan EngineChatStreamOutput),
 		pipe:         "",
-		running:      false,
-	}, nil
+	}
 }
 
 func (e *Engine) SetMode(mode EngineMode) *Engine {
@@ -79,96 +85,171 @@ func (e *Engine) SetPipe(pipe string) *Engine {
 	return e
 }
 
+// SetSampling sets sampling parameters overriding the configured ones
+func (e *Engine) SetSampling(sampling Sampling) *Engine {
+	e.sampling = sampling
+
+	return e
+}
+
+// SetCache enables the exec answers cache
+func (e *Engine) SetCache(cache *Cache) *Engine {
+	e.cache = cache
+
+	return e
+}
+
+// Interrupt cancels the in-flight request, if any
 func (e *Engine) Interrupt() *Engine {
-	e.channel <- EngineChatStreamOutput{
-		content:    "[Interrupt]",
-		last:       true,
-		interrupt:  true,
-		executable: false,
-	}
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
 
-	e.running = false
+	if e.cancel != nil {
+		e.cancel()
+	}
 
 	return e
 }
 
 func (e *Engine) Clear() *Engine {
 	if e.mode == ExecEngineMode {
-		e.execMessages = []openai.ChatCompletionMessage{}
+		e.execMessages = []Message{}
 	} else {
-		e.chatMessages = []openai.ChatCompletionMessage{}
+		e.chatMessages = []Message{}
+		e.summary = ""
 	}
 
 	return e
 }
 
 func (e *Engine) Reset() *Engine {
-	e.execMessages = []openai.ChatCompletionMessage{}
-	e.chatMessages = []openai.ChatCompletionMessage{}
+	e.execMessages = []Message{}
+	e.chatMessages = []Message{}
+	e.summary = ""
 
 	return e
 }
 
-func (e *Engine) ExecCompletion(input string) (*EngineExecOutput, error) {
-	ctx := context.Background()
+func (e *Engine) ExecCompletion(ctx context.Context, input string) (*EngineExecOutput, error) {
+	return e.execCompletion(ctx, input, true)
+}
 
-	e.running = true
+// execCompletion answers an exec prompt, from the cache when enabled and cached is true
+func (e *Engine) execCompletion(ctx context.Context, input string, cached bool) (*EngineExecOutput, error) {
+	ctx, cancel := e.prepareContext(ctx)
+	defer cancel()
+
+	var cacheKey string
+	if e.cache != nil && cached {
+		cacheKey = e.getCacheKey(input)
+		if output, ok := e.cache.Get(cacheKey); ok {
+			content, _ := json.Marshal(output)
+			e.appendUserMessage(input)
+			e.appendAssistantMessage(string(content))
+			output.cached = true
+			output.model = e.config.GetAIConfig().GetModel()
+
+			return output, nil
+		}
+	}
 
 	e.appendUserMessage(input)
 
-	resp, err := e.client.CreateChatCompletion(
-		ctx,
-		openai.ChatCompletionRequest{
-			Model:     e.config.GetAIConfig().GetModel(),
-			MaxTokens: e.config.GetAIConfig().GetMaxTokens(),
-			Messages:  e.prepareCompletionMessages(),
-		},
-	)
-	if err != nil {
-		return nil, err
+	var warnings []string
+	var toolCalls []EngineToolCall
+	var resp *Response
+	var backend Backend
+	var usage Usage
+
+	// the model may inspect the system through the allowed tools before
+	// answering, the last round asks for an answer without tool calls
+	for round := 0; ; round++ {
+		var err error
+		resp, backend, err = e.complete(ctx, func() Request {
+			warnings = appendWarnings(warnings, e.fitContext()...)
+
+			req := e.prepareRequest()
+			if e.config.GetAIConfig().IsStructuredOutput() {
+				req.ResponseFormat = &ResponseFormat{
+					Name:   "exec_output",
+					Schema: execOutputSchema,
+				}
+			}
+			if !e.toolbox.IsEmpty() {
+				req.Tools = e.toolbox.GetDefinitions()
+				req.NoToolCalls = round == maxToolRounds
+			}
+
+			return req
+		})
+		if err != nil {
+			return nil, err
+		}
+		usage = usage.Add(resp.Usage)
+
+		if len(resp.ToolCalls) == 0 {
+			break
+		}
+		if round == maxToolRounds {
+			return nil, fmt.Errorf("too many tool rounds: the model still called tools after %d rounds instead of answering", maxToolRounds)
+		}
+
+		e.appendMessage(Message{
+			Role:      RoleAssistant,
+			Content:   resp.Content,
+			ToolCalls: resp.ToolCalls,
+		})
+
+		for _, call := range resp.ToolCalls {
+			toolCall := e.toolbox.Call(call)
+			toolCalls = append(toolCalls, toolCall)
+
+			e.appendMessage(Message{
+				Role:       RoleTool,
+				Content:    toolCall.GetOutput(),
+				ToolCallID: call.ID,
+			})
+		}
 	}
 
-	content := resp.Choices[0].Message.Content
+	content := resp.Content
 	e.appendAssistantMessage(content)
 
-	var output EngineExecOutput
-	err = json.Unmarshal([]byte(content), &output)
+	output, err := parseExecOutput(content)
 	if err != nil {
-		re := regexp.MustCompile(`\{.*?\}`)
-		match := re.FindString(content)
-		if match != "" {
-			err = json.Unmarshal([]byte(match), &output)
-			if err != nil {
-				return nil, err
-			}
-		} else {
-			output = EngineExecOutput{
-				Command:     "",
-				Explanation: content,
-				Executable:  false,
-			}
-		}
+		return nil, err
+	}
+	output.toolCalls = toolCalls
+	output.backend = backend.GetName()
+	output.model = backend.GetModel()
+	output.usage = usage
+	output.warnings = warnings
+
+	if cacheKey != "" {
+		// caching is best effort, the answer is returned anyway
+		_ = e.cache.Put(cacheKey, output)
 	}
 
 	return &output, nil
 }
 
-func (e *Engine) ChatStreamCompletion(input string) error {
-	ctx := context.Background()
-
-	e.running = true
+func (e *Engine) ChatStreamCompletion(ctx context.Context, input string) error {
+	ctx, cancel := e.prepareContext(ctx)
+	defer cancel()
 
 	e.appendUserMessage(input)
+	warnings := e.summarizeContext(ctx)
 
-	req := openai.ChatCompletionRequest{
-		Model:     e.config.GetAIConfig().GetModel(),
-		MaxTokens: e.config.GetAIConfig().GetMaxTokens(),
-		Messages:  e.prepareCompletionMessages(),
-		Stream:    true,
-	}
+	stream, backend, err := e.stream(ctx, func() Request {
+		warnings = appendWarnings(warnings, e.fitContext()...)
 
-	stream, err := e.client.CreateChatCompletionStream(ctx, req)
+		return e.prepareRequest()
+	})
 	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			e.sendInterrupt()
+			return nil
+		}
 		return err
 	}
 	defer stream.Close()
@@ -176,86 +257,133 @@ func (e *Engine) ChatStreamCompletion(input string) error {
 	var output string
 
 	for {
-		if e.running {
-			resp, err := stream.Recv()
-
-			if errors.Is(err, io.EOF) {
-				executable := false
-				if e.mode == ExecEngineMode {
-					if !strings.HasPrefix(output, noexec) && !strings.Contains(output, "\n") {
-						executable = true
-					}
-				}
+		delta, err := stream.Recv()
 
-				e.channel <- EngineChatStreamOutput{
-					content:    "",
-					last:       true,
-					executable: executable,
+		if errors.Is(err, io.EOF) {
+			executable := false
+			if e.mode == ExecEngineMode {
+				if !strings.HasPrefix(output, noexec) && !strings.Contains(output, "\n") {
+					executable = true
 				}
-				e.running = false
-				e.appendAssistantMessage(output)
-
-				return nil
 			}
 
-			if err != nil {
-				e.running = false
-				return err
+			e.channel <- EngineChatStreamOutput{
+				content:    "",
+				last:       true,
+				executable: executable,
+				backend:    backend.GetName(),
+				model:      backend.GetModel(),
+				warnings:   warnings,
 			}
+			e.appendAssistantMessage(output)
 
-			delta := resp.Choices[0].Delta.Content
-
-			output += delta
+			return nil
+		}
 
-			e.channel <- EngineChatStreamOutput{
-				content: delta,
-				last:    false,
+		if err != nil {
+			if errors.Is(ctx.Err(), context.Canceled) {
+				e.sendInterrupt()
+				return nil
 			}
+			return err
+		}
 
-		} else {
-			stream.Close()
+		output += delta
 
-			return nil
+		e.channel <- EngineChatStreamOutput{
+			content: delta,
+			last:    false,
 		}
 	}
 }
 
-func (e *Engine) appendUserMessage(content string) *Engine {
+// prepareContext derives the request context, bounded by the configured
+// timeout and cancellable through Interrupt
+func (e *Engine) prepareContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	var cancel context.CancelFunc
+	if timeout := e.config.GetAIConfig().GetTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	e.mutex.Lock()
+	e.cancel = cancel
+	e.mutex.Unlock()
+
+	return ctx, cancel
+}
+
+func (e *Engine) sendInterrupt() {
+	e.channel <- EngineChatStreamOutput{
+		content:    "[Interrupt]",
+		last:       true,
+		interrupt:  true,
+		executable: false,
+	}
+}
+
+func (e *Engine) getMessages() []Message {
 	if e.mode == ExecEngineMode {
-		e.execMessages = append(e.execMessages, openai.ChatCompletionMessage{
-			Role:    openai.ChatMessageRoleUser,
-			Content: content,
-		})
+		return e.execMessages
+	}
+
+	return e.chatMessages
+}
+
+func (e *Engine) setMessages(messages []Message) *Engine {
+	if e.mode == ExecEngineMode {
+		e.execMessages = messages
 	} else {
-		e.chatMessages = append(e.chatMessages, openai.ChatCompletionMessage{
-			Role:    openai.ChatMessageRoleUser,
-			Content: content,
-		})
+		e.chatMessages = messages
 	}
 
 	return e
 }
 
-func (e *Engine) appendAssistantMessage(content string) *Engine {
+func (e *Engine) appendMessage(message Message) *Engine {
 	if e.mode == ExecEngineMode {
-		e.execMessages = append(e.execMessages, openai.ChatCompletionMessage{
-			Role:    openai.ChatMessageRoleAssistant,
-			Content: content,
-		})
+		e.execMessages = append(e.execMessages, message)
 	} else {
-		e.chatMessages = append(e.chatMessages, openai.ChatCompletionMessage{
-			Role:    openai.ChatMessageRoleAssistant,
-			Content: content,
-		})
+		e.chatMessages = append(e.chatMessages, message)
 	}
 
 	return e
 }
 
-func (e *Engine) prepareCompletionMessages() []openai.ChatCompletionMessage {
-	messages := []openai.ChatCompletionMessage{
+func (e *Engine) appendUserMessage(content string) *Engine {
+	return e.appendMessage(Message{
+		Role:    RoleUser,
+		Content: content,
+	})
+}
+
+func (e *Engine) appendAssistantMessage(content string) *Engine {
+	return e.appendMessage(Message{
+		Role:    RoleAssistant,
+		Content: content,
+	})
+}
+
+func (e *Engine) prepareRequest() Request {
+	return Request{
+		Model:     e.config.GetAIConfig().GetModel(),
+		MaxTokens: e.config.GetAIConfig().GetMaxTokens(),
+		Messages:  e.prepareCompletionMessages(),
+		Sampling:  e.prepareSampling(),
+	}
+}
+
+func (e *Engine) prepareSampling() Sampling {
+	sampling := NewSampling(e.config.GetAIConfig().GetSamplingConfig(e.mode.String()))
+
+	return sampling.Merge(e.sampling)
+}
+
+func (e *Engine) prepareCompletionMessages() []Message {
+	messages := []Message{
 		{
-			Role:    openai.ChatMessageRoleSystem,
+			Role:    RoleSystem,
 			Content: e.prepareSystemPrompt(),
 		},
 	}
@@ -263,31 +391,42 @@ func (e *Engine) prepareCompletionMessages() []openai.ChatCompletionMessage {
 	if e.pipe != "" {
 		messages = append(
 			messages,
-			openai.ChatCompletionMessage{
-				Role:    openai.ChatMessageRoleUser,
+			Message{
+				Role:    RoleUser,
 				Content: e.preparePipePrompt(),
 			},
 		)
 	}
 
-	if e.mode == ExecEngineMode {
-		messages = append(messages, e.execMessages...)
-	} else {
-		messages = append(messages, e.chatMessages...)
+	if e.mode == ChatEngineMode && e.summary != "" {
+		messages = append(
+			messages,
+			Message{
+				Role:    RoleSystem,
+				Content: e.prepareSummaryPrompt(),
+			},
+		)
 	}
 
+	messages = append(messages, e.getMessages()...)
+
 	return messages
 }
 
 func (e *Engine) preparePipePrompt() string {
-	return fmt.Sprintf("I will work on the following input: %s", e.pipe)
+	return fmt.Sprintf("I will work on the following input: %s", truncateText(e.pipe, e.getPipeBudget()))
 }
 
 func (e *Engine) prepareSystemPrompt() string {
 	var bodyPart string
-	if e.mode == ExecEngineMode {
+	switch {
+	case e.mode == ExecEngineMode && e.fix:
+		bodyPart = e.prepareSystemPromptFixPart()
+	case e.mode == ExecEngineMode:
 		bodyPart = e.prepareSystemPromptExecPart()
-	} else {
+	case e.mode == ExplainEngineMode:
+		bodyPart = e.prepareSystemPromptExplainPart()
+	default:
 		bodyPart = e.prepareSystemPromptChatPart()
 	}
 
@@ -347,6 +486,9 @@ func (e *Engine) prepareSystemPromptContextPart() string {
 	if e.config.GetSystemConfig().GetShell() != "" {
 		sb.WriteString(fmt.Sprintf("my shell is %s, ", e.config.GetSystemConfig().GetShell()))
 	}
+	if e.mode == ExecEngineMode {
+		sb.WriteString(fmt.Sprintf("the commands will be executed with %s so use its syntax, ", run.GetShell(e.config.GetSystemConfig().GetShell())))
+	}
 	if e.config.GetSystemConfig().GetEditor() != "" {
 		sb.WriteString(fmt.Sprintf("my editor is %s, ", e.config.GetSystemConfig().GetEditor()))
 	}
@@ -0,0 +1,162 @@
+package ai
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bmichalkiewicz/gogut/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProvider struct {
+	responses []*Response
+	requests  []Request
+}
+
+func (p *fakeProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	p.requests = append(p.requests, req)
+
+	resp := p.responses[0]
+	p.responses = p.responses[1:]
+
+	return resp, nil
+}
+
+func (p *fakeProvider) Stream(ctx context.Context, req Request) (Stream, error) {
+	return nil, io.EOF
+}
+
+func (p *fakeProvider) ListModels(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+func newTestConfig(t *testing.T, content string) *config.Config {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	cfg, err := config.NewConfig(path)
+	require.NoError(t, err)
+
+	return cfg
+}
+
+func TestEngineExecCompletionTools(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\ntools:\n  allowed: [env]\n")
+	t.Setenv("GOGUT_TEST_EDITOR", "nvim")
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{ToolCalls: []ToolCall{{ID: "call_1", Name: EnvTool, Arguments: `{"name":"GOGUT_TEST_EDITOR"}`}}, Usage: Usage{PromptTokens: 100, CompletionTokens: 10}},
+			{Content: `{"cmd":"nvim ~/.bashrc", "exp": "edit bashrc", "exec": true}`, Usage: Usage{PromptTokens: 120, CompletionTokens: 20}},
+		},
+	}
+
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, provider)
+
+	output, err := engine.ExecCompletion(context.Background(), "edit my bashrc")
+	require.NoError(t, err)
+
+	assert.Equal(t, "nvim ~/.bashrc", output.GetCommand())
+	assert.Equal(t, "test_model", output.GetModel())
+	assert.Equal(t, Usage{PromptTokens: 220, CompletionTokens: 30}, output.GetUsage())
+	assert.Equal(t, 250, output.GetUsage().GetTotalTokens())
+	require.Len(t, output.GetToolCalls(), 1)
+	assert.Equal(t, EnvTool, output.GetToolCalls()[0].GetName())
+	assert.Equal(t, "nvim", output.GetToolCalls()[0].GetOutput())
+
+	require.Len(t, provider.requests, 2)
+	require.Len(t, provider.requests[0].Tools, 1)
+
+	messages := provider.requests[1].Messages
+	require.GreaterOrEqual(t, len(messages), 3)
+	assert.Equal(t, RoleAssistant, messages[len(messages)-2].Role)
+	assert.Equal(t, "call_1", messages[len(messages)-2].ToolCalls[0].ID)
+	assert.Equal(t, Message{Role: RoleTool, Content: "nvim", ToolCallID: "call_1"}, messages[len(messages)-1])
+}
+
+func TestEngineExecCompletionToolRounds(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\ntools:\n  allowed: [env]\n")
+
+	toolCall := &Response{ToolCalls: []ToolCall{{ID: "call", Name: EnvTool, Arguments: `{"name":"HOME"}`}}}
+	answer := &Response{Content: `{"cmd":"ls ~", "exp": "list home", "exec": true}`}
+
+	newProvider := func(last *Response) *fakeProvider {
+		provider := &fakeProvider{}
+		for i := 0; i < maxToolRounds; i++ {
+			provider.responses = append(provider.responses, toolCall)
+		}
+		provider.responses = append(provider.responses, last)
+		return provider
+	}
+
+	// the last round asks for an answer
+	provider := newProvider(answer)
+	output, err := NewEngineWithProvider(ExecEngineMode, cfg, provider).ExecCompletion(context.Background(), "list my home")
+	require.NoError(t, err)
+	assert.Equal(t, "ls ~", output.GetCommand())
+	assert.Len(t, output.GetToolCalls(), maxToolRounds)
+
+	require.Len(t, provider.requests, maxToolRounds+1)
+	assert.False(t, provider.requests[maxToolRounds-1].NoToolCalls)
+	assert.True(t, provider.requests[maxToolRounds].NoToolCalls)
+	assert.Len(t, provider.requests[maxToolRounds].Tools, 1)
+
+	// a model still calling tools gets a clear error
+	provider = newProvider(toolCall)
+	_, err = NewEngineWithProvider(ExecEngineMode, cfg, provider).ExecCompletion(context.Background(), "list my home")
+	assert.ErrorContains(t, err, "too many tool rounds")
+}
+
+type blockingProvider struct {
+	fakeProvider
+	started chan struct{}
+}
+
+func (p *blockingProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	close(p.started)
+	<-ctx.Done()
+
+	return nil, ctx.Err()
+}
+
+func TestEngineInterrupt(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  timeout: 0s\n")
+
+	provider := &blockingProvider{started: make(chan struct{})}
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, provider)
+
+	go func() {
+		<-provider.started
+		engine.Interrupt()
+	}()
+
+	_, err := engine.ExecCompletion(context.Background(), "list files")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestEngineTimeout(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  timeout: 10ms\n")
+
+	provider := &blockingProvider{started: make(chan struct{})}
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, provider)
+
+	_, err := engine.ExecCompletion(context.Background(), "list files")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestEngineSystemPromptShell(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  shell: fish\n")
+
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, &fakeProvider{})
+	assert.Contains(t, engine.prepareSystemPrompt(), "my shell is fish")
+	assert.Contains(t, engine.prepareSystemPrompt(), "the commands will be executed with fish")
+
+	engine.SetMode(ChatEngineMode)
+	assert.NotContains(t, engine.prepareSystemPrompt(), "executed with")
+}
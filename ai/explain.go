@@ -0,0 +1,128 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bmichalkiewicz/gogut/run"
+)
+
+// EngineExplainOutput is the explanation of a command, segment by segment
+type EngineExplainOutput struct {
+	command  string
+	segments []run.Segment
+	content  string
+	backend  string
+	model    string
+	usage    Usage
+}
+
+func (eo EngineExplainOutput) GetCommand() string {
+	return eo.command
+}
+
+// GetSegments returns the stages of the command, as parsed locally
+func (eo EngineExplainOutput) GetSegments() []run.Segment {
+	return eo.segments
+}
+
+// GetContent returns the markdown explanation written by the model
+func (eo EngineExplainOutput) GetContent() string {
+	return eo.content
+}
+
+// GetBackend returns the name of the backend which answered, eg. ollama/llama3
+func (eo EngineExplainOutput) GetBackend() string {
+	return eo.backend
+}
+
+// GetModel returns the model which answered
+func (eo EngineExplainOutput) GetModel() string {
+	return eo.model
+}
+
+// GetUsage returns the tokens spent on the explanation
+func (eo EngineExplainOutput) GetUsage() Usage {
+	return eo.usage
+}
+
+// ExplainCompletion breaks a command down into its stages with the shell
+// parser and asks the model to explain each of them, along with the risks
+// of running it, explanations are independent from each other and from the
+// conversations of the other modes
+func (e *Engine) ExplainCompletion(ctx context.Context, command string) (*EngineExplainOutput, error) {
+	segments, err := run.Breakdown(run.GetShell(e.config.GetSystemConfig().GetShell()), command)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse the command: %w", err)
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no command to explain")
+	}
+
+	ctx, cancel := e.prepareContext(ctx)
+	defer cancel()
+
+	req := Request{
+		Model:     e.config.GetAIConfig().GetModel(),
+		MaxTokens: e.config.GetAIConfig().GetMaxTokens(),
+		Messages: []Message{
+			{
+				Role:    RoleSystem,
+				Content: e.prepareSystemPrompt(),
+			},
+			{
+				Role:    RoleUser,
+				Content: prepareExplainPrompt(command, segments),
+			},
+		},
+		Sampling: e.prepareSampling(),
+	}
+
+	resp, backend, err := e.complete(ctx, func() Request { return req })
+	if err != nil {
+		return nil, err
+	}
+
+	return &EngineExplainOutput{
+		command:  command,
+		segments: segments,
+		content:  strings.TrimSpace(resp.Content),
+		backend:  backend.GetName(),
+		model:    backend.GetModel(),
+		usage:    resp.Usage,
+	}, nil
+}
+
+func prepareExplainPrompt(command string, segments []run.Segment) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("Explain the command `%s`, made of these segments:\n", command))
+	for i, segment := range segments {
+		sb.WriteString(fmt.Sprintf("%d. `%s`", i+1, segment.GetSource()))
+		if operator := segment.GetOperator(); operator != "" {
+			sb.WriteString(fmt.Sprintf(" (after `%s`)", operator))
+		}
+		if flags := segment.GetFlags(); len(flags) > 0 {
+			sb.WriteString(fmt.Sprintf(", flags: %s", strings.Join(flags, " ")))
+		}
+		if redirects := segment.GetRedirects(); len(redirects) > 0 {
+			sb.WriteString(fmt.Sprintf(", redirections: %s", strings.Join(redirects, " ")))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+func (e *Engine) prepareSystemPromptExplainPart() string {
+	var sb strings.Builder
+
+	sb.WriteString("You are Gogut, a powerful terminal assistant explaining the command lines I give you, broken down into segments.\n")
+	sb.WriteString("Always format your answer in markdown format, and never run or rewrite the command.\n")
+	sb.WriteString("For each segment, in order, write a `### <number>. <segment>` title followed by what the segment does, how it uses the output of the previous one, and the meaning of each of its flags and arguments as a list.\n")
+	sb.WriteString("End with a `### Risks` section summarizing what running the whole command could break, delete, overwrite, expose or download, and whether it needs privileges, or saying it is harmless.\n")
+	sb.WriteString("Be concise, don't add any introduction or conclusion.\n")
+
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplain(t *testing.T) {
+	t.Run("ExplainPrompt", testExplainPrompt)
+	t.Run("ExplainCompletion", testExplainCompletion)
+	t.Run("ExplainUnparsable", testExplainUnparsable)
+	t.Run("ExplainUnparsedShell", testExplainUnparsedShell)
+}
+
+func testExplainPrompt(t *testing.T) {
+	engine := NewEngineWithProvider(ExplainEngineMode, newTestConfig(t, "settings:\n  model: test_model\n"), &fakeProvider{})
+
+	output, err := engine.ExplainCompletion(context.Background(), "")
+	assert.Error(t, err)
+	assert.Nil(t, output)
+
+	prompt := engine.prepareSystemPrompt()
+	assert.Contains(t, prompt, "explaining the command lines")
+	assert.Contains(t, prompt, "### Risks")
+	assert.Contains(t, prompt, "My context: ")
+	assert.Equal(t, "explain", ExplainEngineMode.String())
+}
+
+func testExplainCompletion(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n")
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: "\n### 1. `ps aux`\nlists the processes\n### Risks\nharmless\n", Usage: Usage{PromptTokens: 80, CompletionTokens: 30}},
+		},
+	}
+
+	engine := NewEngineWithProvider(ExplainEngineMode, cfg, provider)
+
+	output, err := engine.ExplainCompletion(context.Background(), "ps aux | grep -i nginx > /tmp/out")
+	require.NoError(t, err)
+	assert.Equal(t, "ps aux | grep -i nginx > /tmp/out", output.GetCommand())
+	assert.Equal(t, "### 1. `ps aux`\nlists the processes\n### Risks\nharmless", output.GetContent())
+	assert.Equal(t, "test_model", output.GetModel())
+	assert.Equal(t, 110, output.GetUsage().GetTotalTokens())
+
+	segments := output.GetSegments()
+	require.Len(t, segments, 2)
+	assert.Equal(t, "ps", segments[0].GetName())
+	assert.Equal(t, "grep", segments[1].GetName())
+	assert.Equal(t, "|", segments[1].GetOperator())
+
+	require.Len(t, provider.requests, 1)
+	messages := provider.requests[0].Messages
+	require.Len(t, messages, 2)
+	assert.Equal(t, RoleSystem, messages[0].Role)
+	assert.Equal(t, RoleUser, messages[1].Role)
+	assert.Contains(t, messages[1].Content, "Explain the command `ps aux | grep -i nginx > /tmp/out`")
+	assert.Contains(t, messages[1].Content, "1. `ps aux`\n")
+	assert.Contains(t, messages[1].Content, "2. `grep -i nginx >/tmp/out` (after `|`), flags: -i, redirections: >/tmp/out\n")
+
+	// explanations are not kept in the conversations
+	assert.Empty(t, engine.getMessages())
+}
+
+func testExplainUnparsable(t *testing.T) {
+	provider := &fakeProvider{}
+	engine := NewEngineWithProvider(ExplainEngineMode, newTestConfig(t, "settings:\n  model: test_model\n  shell: bash\n"), provider)
+
+	_, err := engine.ExplainCompletion(context.Background(), "echo 'unterminated")
+	assert.ErrorContains(t, err, "cannot parse the command")
+	assert.Empty(t, provider.requests)
+}
+
+func testExplainUnparsedShell(t *testing.T) {
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: "### 1. `for f in *.txt; echo $f; end`\nprints the text files"},
+		},
+	}
+	engine := NewEngineWithProvider(ExplainEngineMode, newTestConfig(t, "settings:\n  model: test_model\n  shell: fish\n"), provider)
+
+	// fish syntax is explained as a whole instead of being rejected
+	output, err := engine.ExplainCompletion(context.Background(), "for f in *.txt; echo $f; end")
+	require.NoError(t, err)
+	require.Len(t, output.GetSegments(), 1)
+	require.Len(t, provider.requests, 1)
+	assert.Contains(t, provider.requests[0].Messages[1].Content, "1. `for f in *.txt; echo $f; end`\n")
+}
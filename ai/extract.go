@@ -0,0 +1,157 @@
+package ai
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// parseExecOutput decodes a model reply into an EngineExecOutput, extracting
+// the JSON object from surrounding prose or code fences when needed. Replies
+// without any JSON object are returned as a non executable explanation.
+func parseExecOutput(content string) (EngineExecOutput, error) {
+	var output EngineExecOutput
+
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &output); err == nil {
+		return output, nil
+	}
+
+	match, ok := extractJSONObject(content)
+	if !ok {
+		return EngineExecOutput{
+			Command:     "",
+			Explanation: content,
+			Executable:  false,
+		}, nil
+	}
+
+	if err := json.Unmarshal([]byte(match), &output); err != nil {
+		return EngineExecOutput{}, err
+	}
+
+	return output, nil
+}
+
+// extractJSONObject returns the first valid JSON object found in content,
+// looking inside fenced code blocks before scanning the raw content
+func extractJSONObject(content string) (string, bool) {
+	for _, block := range extractFencedBlocks(content) {
+		if match, ok := scanJSONObject(block); ok {
+			return match, true
+		}
+	}
+
+	return scanJSONObject(content)
+}
+
+func extractFencedBlocks(content string) []string {
+	var blocks []string
+
+	parts := strings.Split(content, "```")
+	for i := 1; i < len(parts); i += 2 {
+		block := parts[i]
+		// drop the info string, eg. ```json
+		if newline := strings.IndexByte(block, '\n'); newline >= 0 && !strings.ContainsAny(block[:newline], "{}") {
+			block = block[newline+1:]
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
+
+// scanJSONObject walks content keeping track of brace depth outside of JSON
+// strings, so braces inside commands such as awk '{print $1}' do not end the object
+func scanJSONObject(content string) (string, bool) {
+	for start := strings.IndexByte(content, '{'); start >= 0; {
+		if end, ok := matchBrace(content, start); ok {
+			candidate := content[start : end+1]
+			if json.Valid([]byte(candidate)) {
+				return candidate, true
+			}
+			if repaired := repairJSONEscapes(candidate); json.Valid([]byte(repaired)) {
+				return repaired, true
+			}
+		}
+
+		next := strings.IndexByte(content[start+1:], '{')
+		if next < 0 {
+			break
+		}
+		start += next + 1
+	}
+
+	return "", false
+}
+
+func matchBrace(content string, start int) (int, bool) {
+	depth := 0
+	inString := false
+	escaped := false
+
+	for i := start; i < len(content); i++ {
+		c := content[i]
+
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// repairJSONEscapes doubles backslashes which do not start a valid JSON escape
+// sequence, as models often emit shell escapes verbatim, eg. find -exec rm {} \;
+func repairJSONEscapes(content string) string {
+	var sb strings.Builder
+
+	inString := false
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+
+		if !inString {
+			if c == '"' {
+				inString = true
+			}
+			sb.WriteByte(c)
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = false
+			sb.WriteByte(c)
+		case '\\':
+			if i+1 < len(content) && strings.IndexByte(`"\/bfnrtu`, content[i+1]) >= 0 {
+				sb.WriteByte(c)
+				sb.WriteByte(content[i+1])
+				i++
+			} else {
+				sb.WriteString(`\\`)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String()
+}
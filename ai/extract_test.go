@@ -0,0 +1,122 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseExecOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected EngineExecOutput
+	}{
+		{
+			name:     "plain json",
+			content:  `{"cmd":"ls ~", "exp": "list files", "exec": true}`,
+			expected: EngineExecOutput{Command: "ls ~", Explanation: "list files", Executable: true},
+		},
+		{
+			name:     "fenced json block",
+			content:  "```json\n{\"cmd\":\"df -h\", \"exp\": \"disk usage\", \"exec\": true}\n```",
+			expected: EngineExecOutput{Command: "df -h", Explanation: "disk usage", Executable: true},
+		},
+		{
+			name:     "fenced block without info string",
+			content:  "Here you go:\n```\n{\"cmd\":\"uptime\", \"exp\": \"uptime\", \"exec\": true}\n```\nEnjoy!",
+			expected: EngineExecOutput{Command: "uptime", Explanation: "uptime", Executable: true},
+		},
+		{
+			name:     "prose around json",
+			content:  `Sure! {"cmd":"whoami", "exp": "current user", "exec": true} Let me know if you need more.`,
+			expected: EngineExecOutput{Command: "whoami", Explanation: "current user", Executable: true},
+		},
+		{
+			name:     "braces inside command",
+			content:  `Gogut: {"cmd":"ps aux | awk '{print $1}' | sort -u", "exp": "list users running processes", "exec": true}`,
+			expected: EngineExecOutput{Command: "ps aux | awk '{print $1}' | sort -u", Explanation: "list users running processes", Executable: true},
+		},
+		{
+			name:     "find exec placeholder",
+			content:  `{"cmd":"find . -name '*.log' -exec rm {} \;", "exp": "remove log files", "exec": true}` + "\n",
+			expected: EngineExecOutput{Command: `find . -name '*.log' -exec rm {} \;`, Explanation: "remove log files", Executable: true},
+		},
+		{
+			name:     "escaped quotes and braces in strings",
+			content:  `Result: {"cmd":"echo \"}{\"", "exp": "print \"}{\"", "exec": true}`,
+			expected: EngineExecOutput{Command: `echo "}{"`, Explanation: `print "}{"`, Executable: true},
+		},
+		{
+			name:     "invalid object before valid one",
+			content:  `I think {this} is what you want: {"cmd":"date", "exp": "print date", "exec": true}`,
+			expected: EngineExecOutput{Command: "date", Explanation: "print date", Executable: true},
+		},
+		{
+			name:     "nested object",
+			content:  `{"cmd":"jq '.a'", "exp": "read a", "exec": true, "meta": {"shell": "bash"}}`,
+			expected: EngineExecOutput{Command: "jq '.a'", Explanation: "read a", Executable: true},
+		},
+		{
+			name:     "no json",
+			content:  "I cannot generate a command for this.",
+			expected: EngineExecOutput{Explanation: "I cannot generate a command for this."},
+		},
+		{
+			name:     "truncated json",
+			content:  `{"cmd":"ls -la", "exp": "list`,
+			expected: EngineExecOutput{Explanation: `{"cmd":"ls -la", "exp": "list`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			output, err := parseExecOutput(test.content)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expected, output)
+		})
+	}
+}
+
+func TestExtractJSONObject(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+		found    bool
+	}{
+		{
+			name:     "first valid object wins",
+			content:  `{"a":1} {"b":2}`,
+			expected: `{"a":1}`,
+			found:    true,
+		},
+		{
+			name:     "fenced block preferred over prose",
+			content:  "use {\"a\":1} or\n```json\n{\"b\":2}\n```",
+			expected: `{"b":2}`,
+			found:    true,
+		},
+		{
+			name:    "unterminated string",
+			content: `{"a": "b}`,
+			found:   false,
+		},
+		{
+			name:    "empty content",
+			content: "",
+			found:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			match, found := extractJSONObject(test.content)
+
+			assert.Equal(t, test.found, found)
+			assert.Equal(t, test.expected, match)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// fixOutputTokens bounds the failing command output sent to the model
+const fixOutputTokens = 1000
+
+// SetFix makes the exec completions correct failed commands with a dedicated
+// system prompt, for a fix without the conversation the command came from
+func (e *Engine) SetFix(fix bool) *Engine {
+	e.fix = fix
+
+	return e
+}
+
+// FixCompletion asks the model for a corrected version of a command which
+// failed, given its exit code and output, within the current conversation,
+// fixes are never cached since a cached fix is the one which just failed
+func (e *Engine) FixCompletion(ctx context.Context, command string, exitCode int, output string) (*EngineExecOutput, error) {
+	return e.execCompletion(ctx, prepareFixPrompt(command, exitCode, output), false)
+}
+
+func prepareFixPrompt(command string, exitCode int, output string) string {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		output = "(no output captured)"
+	}
+
+	return fmt.Sprintf(
+		"The command `%s` failed with exit code %d and this output:\n```\n%s\n```\n"+
+			"Find out why and propose a corrected command achieving the same goal.",
+		command,
+		exitCode,
+		truncateText(output, fixOutputTokens),
+	)
+}
+
+func (e *Engine) prepareSystemPromptFixPart() string {
+	var sb strings.Builder
+
+	sb.WriteString("You are Gogut, a powerful terminal assistant generating a JSON containing a corrected command line for a command which failed.\n")
+	sb.WriteString("You will always reply using the following json structure: {\"cmd\":\"the corrected command\", \"exp\": \"some explanation\", \"exec\": true}.\n")
+	sb.WriteString("Your answer will always only contain the json structure, never add any advice or supplementary detail or information, even if I asked the same question before.\n")
+	sb.WriteString("Look for the cause of the failure in the exit code and the output, like a typo, a wrong flag, a missing argument, a missing privilege or a wrong path.\n")
+	sb.WriteString("The field cmd will contain a single line command achieving what the failed command was meant to do (don't use new lines, use separators like && and ; instead).\n")
+	sb.WriteString("The field exp will contain a short explanation of what was wrong if you managed to correct the command, otherwise it will contain the reason of your failure, for example when no command can solve the failure.\n")
+	sb.WriteString("The field exec will contain true if you managed to correct the command, false otherwise.\n")
+	sb.WriteString("\n")
+	sb.WriteString("Examples:\n")
+	sb.WriteString("Me: The command `gti status` failed with exit code 127 and this output: bash: gti: command not found\n")
+	sb.WriteString("Gogut: {\"cmd\":\"git status\", \"exp\": \"gti is a typo of git\", \"exec\": true}\n")
+	sb.WriteString("Me: The command `apt install htop` failed with exit code 100 and this output: E: Could not open lock file /var/lib/dpkg/lock-frontend - open (13: Permission denied)\n")
+	sb.WriteString("Gogut: {\"cmd\":\"sudo apt install htop\", \"exp\": \"installing packages needs root privileges\", \"exec\": true}\n")
+	sb.WriteString("Me: The command `curl https://example.com` failed with exit code 6 and this output: curl: (6) Could not resolve host: example.com\n")
+	sb.WriteString("Gogut: {\"cmd\":\"\", \"exp\": \"the host cannot be resolved, check your network connection and DNS settings\", \"exec\": false}")
+
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+package ai
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFix(t *testing.T) {
+	t.Run("FixPrompt", testFixPrompt)
+	t.Run("FixCompletion", testFixCompletion)
+	t.Run("FixSystemPrompt", testFixSystemPrompt)
+	t.Run("FixNotCached", testFixNotCached)
+}
+
+func testFixPrompt(t *testing.T) {
+	prompt := prepareFixPrompt("ls /nope", 2, "\nls: cannot access '/nope': No such file or directory\n")
+	assert.Contains(t, prompt, "`ls /nope`")
+	assert.Contains(t, prompt, "exit code 2")
+	assert.Contains(t, prompt, "```\nls: cannot access '/nope': No such file or directory\n```")
+
+	assert.Contains(t, prepareFixPrompt("false", 1, "  "), "(no output captured)")
+
+	output := strings.Repeat("a", fixOutputTokens*charsPerToken) + strings.Repeat("z", fixOutputTokens*charsPerToken)
+	prompt = prepareFixPrompt("noisy", 1, output)
+	assert.Contains(t, prompt, pipeTruncatedMarker)
+	assert.Less(t, len(prompt), len(output))
+}
+
+func testFixCompletion(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\ntools:\n  allowed: []\n")
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: `{"cmd":"ls /tmp", "exp": "list tmp", "exec": true}`},
+			{Content: `{"cmd":"ls -d /tmp", "exp": "list tmp itself", "exec": true}`},
+		},
+	}
+
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, provider)
+
+	_, err := engine.ExecCompletion(context.Background(), "list tmp")
+	require.NoError(t, err)
+
+	output, err := engine.FixCompletion(context.Background(), "ls /tmp", 2, "permission denied")
+	require.NoError(t, err)
+	assert.Equal(t, "ls -d /tmp", output.GetCommand())
+
+	require.Len(t, provider.requests, 2)
+	messages := provider.requests[1].Messages
+	last := messages[len(messages)-1]
+	assert.Equal(t, RoleUser, last.Role)
+	assert.Contains(t, last.Content, "`ls /tmp`")
+	assert.Contains(t, last.Content, "permission denied")
+	assert.Equal(t, `{"cmd":"ls /tmp", "exp": "list tmp", "exec": true}`, messages[len(messages)-2].Content)
+}
+
+func testFixSystemPrompt(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\ntools:\n  allowed: []\n")
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: `{"cmd":"git status", "exp": "gti is a typo of git", "exec": true}`},
+		},
+	}
+
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, provider).SetFix(true)
+
+	output, err := engine.FixCompletion(context.Background(), "gti status", 127, "bash: gti: command not found")
+	require.NoError(t, err)
+	assert.Equal(t, "git status", output.GetCommand())
+	assert.Equal(t, "gti is a typo of git", output.GetExplanation())
+
+	require.Len(t, provider.requests, 1)
+	messages := provider.requests[0].Messages
+	require.Len(t, messages, 2)
+	assert.Equal(t, RoleSystem, messages[0].Role)
+	assert.Contains(t, messages[0].Content, "corrected command line for a command which failed")
+	assert.Contains(t, messages[0].Content, "My context: ")
+	assert.NotEqual(t, engine.SetFix(false).prepareSystemPrompt(), messages[0].Content)
+}
+
+func testFixNotCached(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\ntools:\n  allowed: []\n")
+	cache := NewCache(filepath.Join(t.TempDir(), "cache"), time.Hour)
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: `{"cmd":"git status", "exp": "gti is a typo of git", "exec": true}`},
+			{Content: `{"cmd":"git status --short", "exp": "gti is a typo of git", "exec": true}`},
+		},
+	}
+
+	for range provider.responses {
+		engine := NewEngineWithProvider(ExecEngineMode, cfg, provider).SetCache(cache).SetFix(true)
+		output, err := engine.FixCompletion(context.Background(), "gti status", 127, "bash: gti: command not found")
+		require.NoError(t, err)
+		assert.False(t, output.IsCached())
+	}
+
+	assert.Len(t, provider.requests, 2)
+}
@@ -0,0 +1,281 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const ollamaDefaultURL = "http://localhost:11434"
+
+type OllamaProvider struct {
+	client    *http.Client
+	url       string
+	key       string
+	numCtx    int
+	keepAlive string
+}
+
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaTool struct {
+	Type     string `json:"type"`
+	Function struct {
+		Name        string          `json:"name"`
+		Description string          `json:"description"`
+		Parameters  json.RawMessage `json:"parameters"`
+	} `json:"function"`
+}
+
+type ollamaChatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []ollamaMessage        `json:"messages"`
+	Tools     []ollamaTool           `json:"tools,omitempty"`
+	Stream    bool                   `json:"stream"`
+	Format    json.RawMessage        `json:"format,omitempty"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+}
+
+type ollamaChatResponse struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	Error           string        `json:"error,omitempty"`
+	PromptEvalCount int           `json:"prompt_eval_count,omitempty"`
+	EvalCount       int           `json:"eval_count,omitempty"`
+}
+
+type ollamaTagsResponse struct {
+	Models []struct {
+		Name string `json:"name"`
+	} `json:"models"`
+}
+
+func NewOllamaProvider(key, baseURL string, numCtx int, keepAlive string, httpClient *http.Client) *OllamaProvider {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if baseURL == "" {
+		baseURL = ollamaDefaultURL
+	}
+
+	return &OllamaProvider{
+		client:    httpClient,
+		url:       strings.TrimRight(baseURL, "/"),
+		key:       key,
+		numCtx:    numCtx,
+		keepAlive: keepAlive,
+	}
+}
+
+func (p *OllamaProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	resp, err := doJSON(ctx, p.client, http.MethodPost, p.url+"/api/chat", p.prepareHeaders(), p.prepareRequest(req, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var chat ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chat); err != nil {
+		return nil, err
+	}
+
+	if chat.Error != "" {
+		return nil, errors.New(chat.Error)
+	}
+
+	// ollama does not identify tool calls, ids are generated to pair them with their results
+	toolCalls := make([]ToolCall, 0, len(chat.Message.ToolCalls))
+	for i, call := range chat.Message.ToolCalls {
+		toolCalls = append(toolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", i),
+			Name:      call.Function.Name,
+			Arguments: string(call.Function.Arguments),
+		})
+	}
+
+	return &Response{
+		Content:   chat.Message.Content,
+		ToolCalls: toolCalls,
+		Usage: Usage{
+			PromptTokens:     chat.PromptEvalCount,
+			CompletionTokens: chat.EvalCount,
+		},
+	}, nil
+}
+
+func (p *OllamaProvider) Stream(ctx context.Context, req Request) (Stream, error) {
+	resp, err := doJSON(ctx, p.client, http.MethodPost, p.url+"/api/chat", p.prepareHeaders(), p.prepareRequest(req, true))
+	if err != nil {
+		return nil, err
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	return &ollamaStream{
+		body:    resp.Body,
+		scanner: scanner,
+	}, nil
+}
+
+func (p *OllamaProvider) ListModels(ctx context.Context) ([]string, error) {
+	resp, err := doJSON(ctx, p.client, http.MethodGet, p.url+"/api/tags", p.prepareHeaders(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var tags ollamaTagsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, err
+	}
+
+	models := make([]string, 0, len(tags.Models))
+	for _, model := range tags.Models {
+		models = append(models, model.Name)
+	}
+
+	return models, nil
+}
+
+func (p *OllamaProvider) prepareHeaders() map[string]string {
+	if p.key == "" {
+		return nil
+	}
+
+	return map[string]string{
+		"Authorization": "Bearer " + p.key,
+	}
+}
+
+func (p *OllamaProvider) prepareRequest(req Request, stream bool) ollamaChatRequest {
+	messages := make([]ollamaMessage, 0, len(req.Messages))
+	for _, message := range req.Messages {
+		var toolCalls []ollamaToolCall
+		for _, call := range message.ToolCalls {
+			arguments := call.Arguments
+			if arguments == "" {
+				arguments = "{}"
+			}
+
+			var toolCall ollamaToolCall
+			toolCall.Function.Name = call.Name
+			toolCall.Function.Arguments = json.RawMessage(arguments)
+			toolCalls = append(toolCalls, toolCall)
+		}
+
+		messages = append(messages, ollamaMessage{
+			Role:      message.Role,
+			Content:   message.Content,
+			ToolCalls: toolCalls,
+		})
+	}
+
+	// ollama has no tool choice, the tools are left out instead
+	var tools []ollamaTool
+	if !req.NoToolCalls {
+		for _, definition := range req.Tools {
+			tool := ollamaTool{Type: "function"}
+			tool.Function.Name = definition.Name
+			tool.Function.Description = definition.Description
+			tool.Function.Parameters = definition.Parameters
+			tools = append(tools, tool)
+		}
+	}
+
+	options := map[string]interface{}{}
+	if p.numCtx > 0 {
+		options["num_ctx"] = p.numCtx
+	}
+	if req.MaxTokens > 0 {
+		options["num_predict"] = req.MaxTokens
+	}
+	if req.Sampling.Temperature != nil {
+		options["temperature"] = *req.Sampling.Temperature
+	}
+	if req.Sampling.TopP != nil {
+		options["top_p"] = *req.Sampling.TopP
+	}
+	if req.Sampling.Seed != nil {
+		options["seed"] = *req.Sampling.Seed
+	}
+	if len(req.Sampling.Stop) > 0 {
+		options["stop"] = req.Sampling.Stop
+	}
+	if req.Sampling.PresencePenalty != nil {
+		options["presence_penalty"] = *req.Sampling.PresencePenalty
+	}
+	if req.Sampling.FrequencyPenalty != nil {
+		options["frequency_penalty"] = *req.Sampling.FrequencyPenalty
+	}
+
+	var format json.RawMessage
+	if req.ResponseFormat != nil {
+		format = req.ResponseFormat.Schema
+	}
+
+	return ollamaChatRequest{
+		Model:     req.Model,
+		Format:    format,
+		Messages:  messages,
+		Tools:     tools,
+		Stream:    stream,
+		Options:   options,
+		KeepAlive: p.keepAlive,
+	}
+}
+
+type ollamaStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	done    bool
+}
+
+func (s *ollamaStream) Recv() (string, error) {
+	for !s.done && s.scanner.Scan() {
+		line := strings.TrimSpace(s.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return "", err
+		}
+
+		if chunk.Error != "" {
+			return "", errors.New(chunk.Error)
+		}
+
+		s.done = chunk.Done
+
+		return chunk.Message.Content, nil
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", io.EOF
+}
+
+func (s *ollamaStream) Close() error {
+	return s.body.Close()
+}
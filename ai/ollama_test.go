@@ -0,0 +1,153 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOllamaProvider(t *testing.T) {
+	t.Run("Complete", testOllamaComplete)
+	t.Run("Stream", testOllamaStream)
+	t.Run("StreamError", testOllamaStreamError)
+	t.Run("ListModels", testOllamaListModels)
+	t.Run("APIError", testOllamaAPIError)
+	t.Run("NoToolCalls", testOllamaNoToolCalls)
+}
+
+func testOllamaComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/ollama/api/chat", r.URL.Path)
+
+		var body ollamaChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "llama3", body.Model)
+		assert.False(t, body.Stream)
+		assert.Equal(t, "10m", body.KeepAlive)
+		assert.Equal(t, float64(8192), body.Options["num_ctx"])
+		assert.Equal(t, float64(500), body.Options["num_predict"])
+		assert.Equal(t, []ollamaMessage{{Role: RoleUser, Content: "ping"}}, body.Messages)
+		assert.JSONEq(t, string(execOutputSchema), string(body.Format))
+
+		fmt.Fprint(w, `{"message":{"role":"assistant","content":"pong"},"done":true,"prompt_eval_count":20,"eval_count":4}`)
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider("", server.URL+"/ollama/", 8192, "10m", http.DefaultClient)
+
+	resp, err := provider.Complete(context.Background(), Request{
+		Model:          "llama3",
+		MaxTokens:      500,
+		Messages:       []Message{{Role: RoleUser, Content: "ping"}},
+		ResponseFormat: &ResponseFormat{Name: "exec_output", Schema: execOutputSchema},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "pong", resp.Content)
+	assert.Equal(t, Usage{PromptTokens: 20, CompletionTokens: 4}, resp.Usage)
+}
+
+func testOllamaStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body ollamaChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.True(t, body.Stream)
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"po"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"ng"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true}`)
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider("", server.URL, 0, "", http.DefaultClient)
+
+	stream, err := provider.Stream(context.Background(), Request{
+		Model:    "llama3",
+		Messages: []Message{{Role: RoleUser, Content: "ping"}},
+	})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var output string
+	for {
+		delta, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		output += delta
+	}
+
+	assert.Equal(t, "pong", output)
+}
+
+func testOllamaStreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"error":"model crashed"}`)
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider("", server.URL, 0, "", http.DefaultClient)
+
+	stream, err := provider.Stream(context.Background(), Request{Model: "llama3"})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	_, err = stream.Recv()
+	assert.EqualError(t, err, "model crashed")
+}
+
+func testOllamaListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tags", r.URL.Path)
+		assert.Equal(t, http.MethodGet, r.Method)
+
+		fmt.Fprint(w, `{"models":[{"name":"llama3:latest"},{"name":"mistral:7b"}]}`)
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider("", server.URL, 0, "", http.DefaultClient)
+
+	models, err := provider.ListModels(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"llama3:latest", "mistral:7b"}, models)
+}
+
+func testOllamaAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"model 'nope' not found"}`)
+	}))
+	defer server.Close()
+
+	provider := NewOllamaProvider("", server.URL, 0, "", http.DefaultClient)
+
+	_, err := provider.Complete(context.Background(), Request{Model: "nope"})
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+}
+
+func testOllamaNoToolCalls(t *testing.T) {
+	provider := NewOllamaProvider("", "", 0, "", nil)
+
+	req := Request{
+		Messages: []Message{{Role: RoleUser, Content: "ping"}},
+		Tools:    NewToolbox([]string{WhichTool}).GetDefinitions(),
+	}
+	assert.Len(t, provider.prepareRequest(req, false).Tools, 1)
+
+	req.NoToolCalls = true
+	assert.Empty(t, provider.prepareRequest(req, false).Tools)
+}
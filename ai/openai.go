@@ -0,0 +1,255 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+type OpenAIProvider struct {
+	client *openai.Client
+}
+
+func NewOpenAIProvider(key, baseURL string, httpClient *http.Client) (*OpenAIProvider, error) {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	// go-openai errors do not expose response headers, Retry-After is captured by the transport
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	client := *httpClient
+	client.Transport = &retryAfterTransport{base: transport}
+
+	clientConfig := openai.DefaultConfig(key)
+	clientConfig.HTTPClient = &client
+
+	if baseURL != "" {
+		url, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, err
+		}
+
+		clientConfig.BaseURL = url.Scheme + "://" + url.Host + "/v1"
+	}
+
+	return &OpenAIProvider{
+		client: openai.NewClientWithConfig(clientConfig),
+	}, nil
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, req Request) (*Response, error) {
+	var retryAfter time.Duration
+	ctx = context.WithValue(ctx, retryAfterKey{}, &retryAfter)
+
+	resp, err := p.client.CreateChatCompletion(ctx, p.prepareRequest(req, false))
+	if err != nil {
+		return nil, convertOpenAIError(err, retryAfter)
+	}
+
+	if len(resp.Choices) == 0 {
+		return nil, errors.New("empty completion response")
+	}
+
+	toolCalls := make([]ToolCall, 0, len(resp.Choices[0].Message.ToolCalls))
+	for _, call := range resp.Choices[0].Message.ToolCalls {
+		toolCalls = append(toolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+
+	return &Response{
+		Content:   resp.Choices[0].Message.Content,
+		ToolCalls: toolCalls,
+		Usage: Usage{
+			PromptTokens:     resp.Usage.PromptTokens,
+			CompletionTokens: resp.Usage.CompletionTokens,
+		},
+	}, nil
+}
+
+func (p *OpenAIProvider) Stream(ctx context.Context, req Request) (Stream, error) {
+	var retryAfter time.Duration
+	ctx = context.WithValue(ctx, retryAfterKey{}, &retryAfter)
+
+	stream, err := p.client.CreateChatCompletionStream(ctx, p.prepareRequest(req, true))
+	if err != nil {
+		return nil, convertOpenAIError(err, retryAfter)
+	}
+
+	return &openAIStream{stream: stream}, nil
+}
+
+func (p *OpenAIProvider) ListModels(ctx context.Context) ([]string, error) {
+	list, err := p.client.ListModels(ctx)
+	if err != nil {
+		return nil, convertOpenAIError(err, 0)
+	}
+
+	models := make([]string, 0, len(list.Models))
+	for _, model := range list.Models {
+		models = append(models, model.ID)
+	}
+
+	return models, nil
+}
+
+func (p *OpenAIProvider) prepareRequest(req Request, stream bool) openai.ChatCompletionRequest {
+	messages := make([]openai.ChatCompletionMessage, 0, len(req.Messages))
+	for _, message := range req.Messages {
+		toolCalls := make([]openai.ToolCall, 0, len(message.ToolCalls))
+		for _, call := range message.ToolCalls {
+			toolCalls = append(toolCalls, openai.ToolCall{
+				ID:   call.ID,
+				Type: openai.ToolTypeFunction,
+				Function: openai.FunctionCall{
+					Name:      call.Name,
+					Arguments: call.Arguments,
+				},
+			})
+		}
+
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:       message.Role,
+			Content:    message.Content,
+			ToolCalls:  toolCalls,
+			ToolCallID: message.ToolCallID,
+		})
+	}
+
+	tools := make([]openai.Tool, 0, len(req.Tools))
+	for _, tool := range req.Tools {
+		tools = append(tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	request := openai.ChatCompletionRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		Messages:  messages,
+		Stream:    stream,
+		Seed:      req.Sampling.Seed,
+		Stop:      req.Sampling.Stop,
+	}
+
+	if len(tools) > 0 {
+		request.Tools = tools
+		if req.NoToolCalls {
+			request.ToolChoice = "none"
+		}
+	}
+
+	if req.ResponseFormat != nil {
+		request.ResponseFormat = &openai.ChatCompletionResponseFormat{
+			Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+			JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+				Name:   req.ResponseFormat.Name,
+				Schema: req.ResponseFormat.Schema,
+				Strict: true,
+			},
+		}
+	}
+
+	if req.Sampling.Temperature != nil {
+		request.Temperature = openAIFloat(*req.Sampling.Temperature)
+	}
+	if req.Sampling.TopP != nil {
+		request.TopP = openAIFloat(*req.Sampling.TopP)
+	}
+	if req.Sampling.PresencePenalty != nil {
+		request.PresencePenalty = openAIFloat(*req.Sampling.PresencePenalty)
+	}
+	if req.Sampling.FrequencyPenalty != nil {
+		request.FrequencyPenalty = openAIFloat(*req.Sampling.FrequencyPenalty)
+	}
+
+	return request
+}
+
+// openAIFloat works around go-openai omitting zero values: an explicit zero is
+// sent as the smallest non zero float instead of falling back on the API default
+func openAIFloat(value float64) float32 {
+	if value == 0 {
+		return math.SmallestNonzeroFloat32
+	}
+
+	return float32(value)
+}
+
+// convertOpenAIError maps go-openai HTTP errors on APIError so retries work the same for every provider
+func convertOpenAIError(err error, retryAfter time.Duration) error {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return &APIError{
+			StatusCode: apiErr.HTTPStatusCode,
+			Message:    apiErr.Message,
+			RetryAfter: retryAfter,
+		}
+	}
+
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) {
+		return &APIError{
+			StatusCode: requestErr.HTTPStatusCode,
+			Message:    requestErr.Error(),
+			RetryAfter: retryAfter,
+		}
+	}
+
+	return err
+}
+
+type retryAfterKey struct{}
+
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if retryAfter, ok := req.Context().Value(retryAfterKey{}).(*time.Duration); ok {
+		*retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	}
+
+	return resp, nil
+}
+
+type openAIStream struct {
+	stream *openai.ChatCompletionStream
+}
+
+func (s *openAIStream) Recv() (string, error) {
+	resp, err := s.stream.Recv()
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", nil
+	}
+
+	return resp.Choices[0].Delta.Content, nil
+}
+
+func (s *openAIStream) Close() error {
+	return s.stream.Close()
+}
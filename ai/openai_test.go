@@ -0,0 +1,193 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenAIProvider(t *testing.T) {
+	t.Run("Complete", testOpenAIComplete)
+	t.Run("Sampling", testOpenAISampling)
+	t.Run("ResponseFormat", testOpenAIResponseFormat)
+	t.Run("NoToolCalls", testOpenAINoToolCalls)
+	t.Run("Stream", testOpenAIStream)
+	t.Run("ListModels", testOpenAIListModels)
+}
+
+func testOpenAIComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer test_key", r.Header.Get("Authorization"))
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "test_model", body["model"])
+		assert.Len(t, body["messages"], 2)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"pong"}}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("test_key", server.URL, http.DefaultClient)
+	require.NoError(t, err)
+
+	resp, err := provider.Complete(context.Background(), Request{
+		Model: "test_model",
+		Messages: []Message{
+			{Role: RoleSystem, Content: "system"},
+			{Role: RoleUser, Content: "ping"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "pong", resp.Content)
+	assert.Equal(t, Usage{PromptTokens: 12, CompletionTokens: 3}, resp.Usage)
+}
+
+func testOpenAISampling(t *testing.T) {
+	temperature := 0.0
+	topP := 0.9
+	seed := 42
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Contains(t, body, "temperature")
+		assert.InDelta(t, 0.0, body["temperature"], 0.0001)
+		assert.InDelta(t, topP, body["top_p"], 0.0001)
+		assert.Equal(t, float64(seed), body["seed"])
+		assert.Equal(t, []interface{}{"END"}, body["stop"])
+		assert.NotContains(t, body, "presence_penalty")
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"pong"}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("test_key", server.URL, http.DefaultClient)
+	require.NoError(t, err)
+
+	_, err = provider.Complete(context.Background(), Request{
+		Model:    "test_model",
+		Messages: []Message{{Role: RoleUser, Content: "ping"}},
+		Sampling: Sampling{
+			Temperature: &temperature,
+			TopP:        &topP,
+			Seed:        &seed,
+			Stop:        []string{"END"},
+		},
+	})
+	require.NoError(t, err)
+}
+
+func testOpenAIResponseFormat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResponseFormat struct {
+				Type       string `json:"type"`
+				JSONSchema struct {
+					Name   string                 `json:"name"`
+					Strict bool                   `json:"strict"`
+					Schema map[string]interface{} `json:"schema"`
+				} `json:"json_schema"`
+			} `json:"response_format"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "json_schema", body.ResponseFormat.Type)
+		assert.Equal(t, "exec_output", body.ResponseFormat.JSONSchema.Name)
+		assert.True(t, body.ResponseFormat.JSONSchema.Strict)
+		assert.Equal(t, "object", body.ResponseFormat.JSONSchema.Schema["type"])
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"{}"}}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("test_key", server.URL, http.DefaultClient)
+	require.NoError(t, err)
+
+	_, err = provider.Complete(context.Background(), Request{
+		Model:          "test_model",
+		Messages:       []Message{{Role: RoleUser, Content: "ping"}},
+		ResponseFormat: &ResponseFormat{Name: "exec_output", Schema: execOutputSchema},
+	})
+	require.NoError(t, err)
+}
+
+func testOpenAIStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"po\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"ng\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("test_key", server.URL, http.DefaultClient)
+	require.NoError(t, err)
+
+	stream, err := provider.Stream(context.Background(), Request{
+		Model:    "test_model",
+		Messages: []Message{{Role: RoleUser, Content: "ping"}},
+	})
+	require.NoError(t, err)
+	defer stream.Close()
+
+	var output string
+	for {
+		delta, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		output += delta
+	}
+
+	assert.Equal(t, "pong", output)
+}
+
+func testOpenAIListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/models", r.URL.Path)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"list","data":[{"id":"model_a"},{"id":"model_b"}]}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("test_key", server.URL, http.DefaultClient)
+	require.NoError(t, err)
+
+	models, err := provider.ListModels(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"model_a", "model_b"}, models)
+}
+
+func testOpenAINoToolCalls(t *testing.T) {
+	provider, err := NewOpenAIProvider("test_key", "", http.DefaultClient)
+	require.NoError(t, err)
+
+	req := Request{
+		Messages: []Message{{Role: RoleUser, Content: "ping"}},
+		Tools:    NewToolbox([]string{WhichTool}).GetDefinitions(),
+	}
+	assert.Nil(t, provider.prepareRequest(req, false).ToolChoice)
+
+	req.NoToolCalls = true
+	request := provider.prepareRequest(req, false)
+	require.Len(t, request.Tools, 1)
+	assert.Equal(t, "none", request.ToolChoice)
+}
@@ -1,9 +1,29 @@
 package ai
 
+import "encoding/json"
+
+var execOutputSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"cmd": {"type": "string"},
+		"exp": {"type": "string"},
+		"exec": {"type": "boolean"}
+	},
+	"required": ["cmd", "exp", "exec"],
+	"additionalProperties": false
+}`)
+
 type EngineExecOutput struct {
 	Command     string `json:"cmd"`
 	Explanation string `json:"exp"`
 	Executable  bool   `json:"exec"`
+
+	toolCalls []EngineToolCall
+	backend   string
+	model     string
+	usage     Usage
+	warnings  []string
+	cached    bool
 }
 
 func (eo EngineExecOutput) GetCommand() string {
@@ -18,11 +38,67 @@ func (eo EngineExecOutput) IsExecutable() bool {
 	return eo.Executable
 }
 
+func (eo EngineExecOutput) GetToolCalls() []EngineToolCall {
+	return eo.toolCalls
+}
+
+// GetBackend returns the name of the backend which answered, eg. ollama/llama3
+func (eo EngineExecOutput) GetBackend() string {
+	return eo.backend
+}
+
+// GetModel returns the model which answered
+func (eo EngineExecOutput) GetModel() string {
+	return eo.model
+}
+
+// GetUsage returns the tokens spent on the answer, every tool round
+// included, none for cached answers
+func (eo EngineExecOutput) GetUsage() Usage {
+	return eo.usage
+}
+
+// GetWarnings returns the context window adjustments made for the request
+func (eo EngineExecOutput) GetWarnings() []string {
+	return eo.warnings
+}
+
+// IsCached tells if the answer comes from the cache rather than the model
+func (eo EngineExecOutput) IsCached() bool {
+	return eo.cached
+}
+
+type EngineToolCall struct {
+	name      string
+	arguments string
+	output    string
+	failed    bool
+}
+
+func (tc EngineToolCall) GetName() string {
+	return tc.name
+}
+
+func (tc EngineToolCall) GetArguments() string {
+	return tc.arguments
+}
+
+func (tc EngineToolCall) GetOutput() string {
+	return tc.output
+}
+
+func (tc EngineToolCall) HasFailed() bool {
+	return tc.failed
+}
+
 type EngineChatStreamOutput struct {
 	content    string
 	last       bool
 	interrupt  bool
 	executable bool
+	backend    string
+	model      string
+	warnings   []string
 }
 
 func (co EngineChatStreamOutput) GetContent() string {
@@ -40,3 +116,18 @@ func (co EngineChatStreamOutput) IsInterrupt() bool {
 func (co EngineChatStreamOutput) IsExecutable() bool {
 	return co.executable
 }
+
+// GetBackend returns the name of the backend which answered, set on the last output only
+func (co EngineChatStreamOutput) GetBackend() string {
+	return co.backend
+}
+
+// GetModel returns the model which answered, set on the last output only
+func (co EngineChatStreamOutput) GetModel() string {
+	return co.model
+}
+
+// GetWarnings returns the context window adjustments made for the request, set on the last output only
+func (co EngineChatStreamOutput) GetWarnings() []string {
+	return co.warnings
+}
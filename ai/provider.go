@@ -0,0 +1,180 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bmichalkiewicz/gogut/config"
+)
+
+const (
+	OpenAIProviderName    = "openai"
+	OllamaProviderName    = "ollama"
+	AnthropicProviderName = "anthropic"
+)
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Message is a single provider agnostic chat message, assistant messages may
+// carry tool calls and tool messages answer the call identified by ToolCallID
+type Message struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a tool invocation requested by the model, Arguments being a JSON object
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolDefinition describes a tool the model may call, Parameters being a JSON schema
+type ToolDefinition struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+// Sampling holds the optional sampling parameters, nil values are left to the backend defaults
+type Sampling struct {
+	Temperature      *float64
+	TopP             *float64
+	Seed             *int
+	Stop             []string
+	PresencePenalty  *float64
+	FrequencyPenalty *float64
+}
+
+// Merge returns a copy of s with every parameter set in overrides applied on top
+func (s Sampling) Merge(overrides Sampling) Sampling {
+	if overrides.Temperature != nil {
+		s.Temperature = overrides.Temperature
+	}
+	if overrides.TopP != nil {
+		s.TopP = overrides.TopP
+	}
+	if overrides.Seed != nil {
+		s.Seed = overrides.Seed
+	}
+	if overrides.Stop != nil {
+		s.Stop = overrides.Stop
+	}
+	if overrides.PresencePenalty != nil {
+		s.PresencePenalty = overrides.PresencePenalty
+	}
+	if overrides.FrequencyPenalty != nil {
+		s.FrequencyPenalty = overrides.FrequencyPenalty
+	}
+
+	return s
+}
+
+// ResponseFormat constrains the reply to a JSON document matching Schema,
+// backends without structured outputs support ignore it
+type ResponseFormat struct {
+	Name   string
+	Schema json.RawMessage
+}
+
+// Request is a provider agnostic completion request
+type Request struct {
+	Model          string
+	MaxTokens      int
+	Messages       []Message
+	Sampling       Sampling
+	ResponseFormat *ResponseFormat
+	Tools          []ToolDefinition
+	// NoToolCalls asks for an answer without calling the tools, which stay
+	// defined for the calls already in the conversation
+	NoToolCalls bool
+}
+
+// Response is a provider agnostic completion response
+type Response struct {
+	Content   string
+	ToolCalls []ToolCall
+	Usage     Usage
+}
+
+// Usage counts the tokens of a completion, as reported by the provider
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+// GetTotalTokens returns the tokens of the prompt and the completion
+func (u Usage) GetTotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// Add sums the usage of several completions, eg. the rounds of a tool loop
+func (u Usage) Add(other Usage) Usage {
+	return Usage{
+		PromptTokens:     u.PromptTokens + other.PromptTokens,
+		CompletionTokens: u.CompletionTokens + other.CompletionTokens,
+	}
+}
+
+// Stream yields completion deltas until io.EOF
+type Stream interface {
+	Recv() (string, error)
+	Close() error
+}
+
+// Provider is implemented by every LLM backend usable by the Engine
+type Provider interface {
+	Complete(ctx context.Context, req Request) (*Response, error)
+	Stream(ctx context.Context, req Request) (Stream, error)
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+func NewSampling(config config.SamplingConfig) Sampling {
+	return Sampling{
+		Temperature:      config.GetTemperature(),
+		TopP:             config.GetTopP(),
+		Seed:             config.GetSeed(),
+		Stop:             config.GetStop(),
+		PresencePenalty:  config.GetPresencePenalty(),
+		FrequencyPenalty: config.GetFrequencyPenalty(),
+	}
+}
+
+func NewProvider(config config.AIConfig) (Provider, error) {
+	httpClient := newHTTPClient(config.GetConnectTimeout())
+
+	switch config.GetProvider() {
+	case "", OpenAIProviderName:
+		return NewOpenAIProvider(config.GetKey(), config.GetURL(), httpClient)
+	case OllamaProviderName:
+		return NewOllamaProvider(
+			config.GetKey(),
+			config.GetURL(),
+			config.GetOllamaConfig().GetNumCtx(),
+			config.GetOllamaConfig().GetKeepAlive(),
+			httpClient,
+		), nil
+	case AnthropicProviderName:
+		key := config.GetAnthropicConfig().GetKey()
+		if key == "" {
+			key = config.GetKey()
+		}
+
+		return NewAnthropicProvider(
+			key,
+			config.GetAnthropicConfig().GetURL(),
+			config.GetAnthropicConfig().GetVersion(),
+			httpClient,
+		), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q", config.GetProvider())
+	}
+}
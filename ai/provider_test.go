@@ -0,0 +1,22 @@
+package ai
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplingMerge(t *testing.T) {
+	temperature := 0.2
+	override := 0.9
+	seed := 7
+
+	base := Sampling{Temperature: &temperature, Stop: []string{"END"}}
+	merged := base.Merge(Sampling{Temperature: &override, Seed: &seed})
+
+	assert.Equal(t, &override, merged.Temperature)
+	assert.Equal(t, &seed, merged.Seed)
+	assert.Equal(t, []string{"END"}, merged.Stop)
+	assert.Nil(t, merged.TopP)
+	assert.Equal(t, &temperature, base.Temperature)
+}
@@ -0,0 +1,223 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/bmichalkiewicz/gogut/config"
+)
+
+const maxRetryDelay = 30 * time.Second
+
+// Backend is a provider bound to a model, the engine tries its backends in order
+type Backend struct {
+	name     string
+	model    string
+	provider Provider
+	// window is the context window of the model, in tokens
+	window int
+	// noResponseFormat is set once the backend rejected a response format
+	noResponseFormat bool
+}
+
+func NewBackend(name, model string, provider Provider) Backend {
+	return Backend{
+		name:     name,
+		model:    model,
+		provider: provider,
+		window:   getModelContextWindow(model),
+	}
+}
+
+func (b Backend) GetName() string {
+	return b.name
+}
+
+func (b Backend) GetModel() string {
+	return b.model
+}
+
+func NewBackends(aiConfig config.AIConfig) ([]Backend, error) {
+	var backends []Backend
+
+	for _, backendConfig := range append([]config.AIConfig{aiConfig}, aiConfig.GetFallbacks()...) {
+		provider, err := NewProvider(backendConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		backend := NewBackend(getBackendName(backendConfig), backendConfig.GetModel(), provider)
+		backend.window = getConfigContextWindow(backendConfig)
+		backends = append(backends, backend)
+	}
+
+	return backends, nil
+}
+
+func getBackendName(config config.AIConfig) string {
+	provider := config.GetProvider()
+	if provider == "" {
+		provider = OpenAIProviderName
+	}
+
+	return fmt.Sprintf("%s/%s", provider, config.GetModel())
+}
+
+type RetryPolicy struct {
+	retries int
+	backoff time.Duration
+}
+
+func NewRetryPolicy(retries int, backoff time.Duration) RetryPolicy {
+	return RetryPolicy{
+		retries: retries,
+		backoff: backoff,
+	}
+}
+
+// do runs fn until it succeeds, fails with a non retryable error or the
+// retries are exhausted, waiting between attempts
+func (p RetryPolicy) do(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= p.retries || !isRetryable(err) {
+			return err
+		}
+
+		timer := time.NewTimer(p.delay(attempt, err))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return withContextError(ctx, err)
+		case <-timer.C:
+		}
+	}
+}
+
+// withContextError joins the context error to err, so a request cancelled
+// while waiting for a retry is reported as cancelled, not as the last failure
+func withContextError(ctx context.Context, err error) error {
+	if ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+
+	return errors.Join(ctx.Err(), err)
+}
+
+// delay honors Retry-After when given, otherwise applies a full jitter exponential backoff
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > maxRetryDelay {
+			return maxRetryDelay
+		}
+		return apiErr.RetryAfter
+	}
+
+	ceiling := p.backoff << attempt
+	if ceiling <= 0 || ceiling > maxRetryDelay {
+		ceiling = maxRetryDelay
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+func isRetryable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.StatusCode == http.StatusTooManyRequests ||
+		apiErr.StatusCode == http.StatusRequestTimeout ||
+		apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+func isBadRequest(err error) bool {
+	var apiErr *APIError
+
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+}
+
+// complete sends the request to each backend in turn until one succeeds, the
+// request is prepared for each of them so it fits their context window, and
+// backends rejecting the response format get it once more without it
+func (e *Engine) complete(ctx context.Context, prepare func() Request) (*Response, Backend, error) {
+	var errs []error
+	defer e.setBackend(0)
+
+	for i, backend := range e.backends {
+		e.setBackend(i)
+		backendReq := prepare()
+		backendReq.Model = backend.model
+		if backend.noResponseFormat {
+			backendReq.ResponseFormat = nil
+		}
+
+		var resp *Response
+		send := func() error {
+			var err error
+			resp, err = backend.provider.Complete(ctx, backendReq)
+			return err
+		}
+
+		err := e.retry.do(ctx, send)
+		// OpenAI compatible servers without json_schema support reply 400,
+		// the reply format is then only asked by the system prompt
+		if isBadRequest(err) && backendReq.ResponseFormat != nil {
+			backendReq.ResponseFormat = nil
+			if err = e.retry.do(ctx, send); err == nil {
+				e.backends[i].noResponseFormat = true
+			}
+		}
+		if err == nil {
+			return resp, backend, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, backend, withContextError(ctx, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", backend.name, err))
+	}
+
+	return nil, Backend{}, errors.Join(errs...)
+}
+
+// stream opens a stream on each backend in turn until one succeeds, failures
+// once the stream is opened are not retried
+func (e *Engine) stream(ctx context.Context, prepare func() Request) (Stream, Backend, error) {
+	var errs []error
+	defer e.setBackend(0)
+
+	for i, backend := range e.backends {
+		e.setBackend(i)
+		req := prepare()
+		req.Model = backend.model
+
+		var stream Stream
+		err := e.retry.do(ctx, func() error {
+			var err error
+			stream, err = backend.provider.Stream(ctx, req)
+			return err
+		})
+		if err == nil {
+			return stream, backend, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, backend, withContextError(ctx, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", backend.name, err))
+	}
+
+	return nil, Backend{}, errors.Join(errs...)
+}
+
+// setBackend sets the backend being asked, whose context window bounds the requests
+func (e *Engine) setBackend(backend int) {
+	e.backend = backend
+}
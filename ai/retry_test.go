@@ -0,0 +1,208 @@
+package ai
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const execReply = `{"message":{"role":"assistant","content":"{\"cmd\":\"ls\", \"exp\": \"list\", \"exec\": true}"},"done":true}`
+
+func newScriptedServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(&calls, 1)) - 1
+
+		status := http.StatusOK
+		if call < len(statuses) {
+			status = statuses[call]
+		}
+
+		if status != http.StatusOK {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"error":"scripted failure"}`)
+			return
+		}
+
+		fmt.Fprint(w, execReply)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func TestEngineRetry(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  retries: 2\n  retry_backoff: 1ms\n  timeout: 0s\n")
+
+	server, calls := newScriptedServer(t, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+
+	engine := NewEngineWithBackends(ExecEngineMode, cfg, []Backend{
+		NewBackend("ollama/primary", "primary", NewOllamaProvider("", server.URL, 0, "", nil)),
+	})
+
+	output, err := engine.ExecCompletion(context.Background(), "list files")
+	require.NoError(t, err)
+
+	assert.Equal(t, "ls", output.GetCommand())
+	assert.Equal(t, "ollama/primary", output.GetBackend())
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+}
+
+func TestEngineRetryExhausted(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  retries: 1\n  retry_backoff: 1ms\n  timeout: 0s\n")
+
+	server, calls := newScriptedServer(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+
+	engine := NewEngineWithBackends(ExecEngineMode, cfg, []Backend{
+		NewBackend("ollama/primary", "primary", NewOllamaProvider("", server.URL, 0, "", nil)),
+	})
+
+	_, err := engine.ExecCompletion(context.Background(), "list files")
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestEngineInterruptRetry(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  retries: 3\n  retry_backoff: 1ms\n  timeout: 0s\n")
+
+	failed := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the retry waits 30s, unless interrupted
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprint(w, `{"error":"down"}`)
+		failed <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+
+	engine := NewEngineWithBackends(ExecEngineMode, cfg, []Backend{
+		NewBackend("ollama/primary", "primary", NewOllamaProvider("", server.URL, 0, "", nil)),
+	})
+
+	go func() {
+		<-failed
+		// leaves the time to read the reply and start waiting
+		time.Sleep(100 * time.Millisecond)
+		engine.Interrupt()
+	}()
+
+	start := time.Now()
+	_, err := engine.ExecCompletion(context.Background(), "list files")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
+
+func TestEngineFallback(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  retries: 1\n  retry_backoff: 1ms\n  timeout: 0s\n")
+
+	primary, primaryCalls := newScriptedServer(t, http.StatusUnauthorized)
+	fallback, fallbackCalls := newScriptedServer(t)
+
+	engine := NewEngineWithBackends(ExecEngineMode, cfg, []Backend{
+		NewBackend("ollama/primary", "primary", NewOllamaProvider("", primary.URL, 0, "", nil)),
+		NewBackend("ollama/fallback", "fallback", NewOllamaProvider("", fallback.URL, 0, "", nil)),
+	})
+
+	output, err := engine.ExecCompletion(context.Background(), "list files")
+	require.NoError(t, err)
+
+	assert.Equal(t, "ollama/fallback", output.GetBackend())
+	assert.Equal(t, int32(1), atomic.LoadInt32(primaryCalls), "non retryable errors should not be retried")
+	assert.Equal(t, int32(1), atomic.LoadInt32(fallbackCalls))
+}
+
+func TestEngineResponseFormatRejected(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  retries: 1\n  retry_backoff: 1ms\n  timeout: 0s\n")
+
+	var calls, rejected int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "response_format") {
+			atomic.AddInt32(&rejected, 1)
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"response_format json_schema is not supported"}}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"{\"cmd\":\"ls\", \"exp\": \"list\", \"exec\": true}"}}]}`)
+	}))
+	t.Cleanup(server.Close)
+
+	provider, err := NewOpenAIProvider("key", server.URL, nil)
+	require.NoError(t, err)
+
+	engine := NewEngineWithBackends(ExecEngineMode, cfg, []Backend{
+		NewBackend("openai/primary", "primary", provider),
+	})
+
+	output, err := engine.ExecCompletion(context.Background(), "list files")
+	require.NoError(t, err)
+	assert.Equal(t, "ls", output.GetCommand())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// the backend is not asked for the response format anymore
+	_, err = engine.ExecCompletion(context.Background(), "list files again")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&rejected))
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := NewRetryPolicy(3, 100*time.Millisecond)
+
+	assert.Equal(t, 2*time.Second, policy.delay(0, &APIError{StatusCode: 429, RetryAfter: 2 * time.Second}))
+	assert.Equal(t, maxRetryDelay, policy.delay(0, &APIError{StatusCode: 429, RetryAfter: time.Hour}))
+
+	for attempt := 0; attempt < 3; attempt++ {
+		delay := policy.delay(attempt, &APIError{StatusCode: 503})
+		assert.GreaterOrEqual(t, delay, time.Duration(0))
+		assert.LessOrEqual(t, delay, 100*time.Millisecond<<attempt)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3"))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(""))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon"))
+
+	delay := parseRetryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.Greater(t, delay, 50*time.Second)
+}
+
+func TestOpenAIRetryAfter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"error":{"message":"rate limited","type":"requests"}}`)
+	}))
+	defer server.Close()
+
+	provider, err := NewOpenAIProvider("test_key", server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = provider.Complete(context.Background(), Request{Model: "test_model"})
+
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.Equal(t, 7*time.Second, apiErr.RetryAfter)
+	assert.True(t, isRetryable(err))
+}
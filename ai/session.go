@@ -0,0 +1,69 @@
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Session is the persisted state of a chat, so it can be resumed later on
+type Session struct {
+	Summary  string    `json:"summary"`
+	Messages []Message `json:"messages"`
+}
+
+// GetSession returns the current chat state
+func (e *Engine) GetSession() Session {
+	return Session{
+		Summary:  e.summary,
+		Messages: e.chatMessages,
+	}
+}
+
+// SetSession restores a chat state, usually loaded with LoadSession
+func (e *Engine) SetSession(session Session) *Engine {
+	e.summary = session.Summary
+	e.chatMessages = append([]Message{}, session.Messages...)
+
+	return e
+}
+
+// LoadSession reads a session file, a missing file gives an empty session
+func LoadSession(path string) (Session, error) {
+	var session Session
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return session, nil
+	}
+	if err != nil {
+		return session, err
+	}
+
+	if err := json.Unmarshal(content, &session); err != nil {
+		return session, err
+	}
+
+	return session, nil
+}
+
+// SaveSession writes a session file, replacing the previous one atomically
+func SaveSession(path string, session Session) error {
+	content, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,127 @@
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// summaryThreshold is the share of the context budget the chat may use before older turns are summarized
+	summaryThreshold = 0.75
+	// summaryKeepTurns is the number of latest turns always sent verbatim
+	summaryKeepTurns = 2
+)
+
+const summarySystemPrompt = "You summarize conversations between a user and an assistant. " +
+	"Write a concise summary keeping the facts, decisions, commands, file names and open questions needed to continue the conversation. " +
+	"Merge the previous summary, if any, with the new turns. " +
+	"Reply with the summary only."
+
+// GetSummary returns the rolling summary of the older chat turns, empty until the chat grows large enough
+func (e *Engine) GetSummary() string {
+	return e.summary
+}
+
+func (e *Engine) SetSummary(summary string) *Engine {
+	e.summary = summary
+
+	return e
+}
+
+// summarizeContext replaces the older chat turns with a rolling summary once
+// they take too much of the context budget, failures are returned as warnings
+// and left to fitContext
+func (e *Engine) summarizeContext(ctx context.Context) []string {
+	if e.mode != ChatEngineMode {
+		return nil
+	}
+
+	messages := e.getMessages()
+	if float64(estimateMessagesTokens(messages)) <= float64(e.getContextBudget())*summaryThreshold {
+		return nil
+	}
+
+	split := getKeptTurnsStart(messages, summaryKeepTurns)
+	if split == 0 {
+		return nil
+	}
+
+	summary, err := e.summarize(ctx, messages[:split])
+	if err != nil {
+		return []string{fmt.Sprintf("older messages could not be summarized: %s", err)}
+	}
+
+	e.summary = summary
+	e.setMessages(messages[split:])
+
+	return []string{fmt.Sprintf("%d older messages summarized to fit the context window", split)}
+}
+
+func (e *Engine) summarize(ctx context.Context, messages []Message) (string, error) {
+	req := Request{
+		Model:     e.config.GetAIConfig().GetModel(),
+		MaxTokens: e.config.GetAIConfig().GetMaxTokens(),
+		Messages: []Message{
+			{
+				Role:    RoleSystem,
+				Content: summarySystemPrompt,
+			},
+			{
+				Role:    RoleUser,
+				Content: e.prepareSummaryRequestPrompt(messages),
+			},
+		},
+		Sampling: e.prepareSampling(),
+	}
+
+	resp, _, err := e.complete(ctx, func() Request { return req })
+	if err != nil {
+		return "", err
+	}
+
+	summary := strings.TrimSpace(resp.Content)
+	if summary == "" {
+		return "", errors.New("empty summary")
+	}
+
+	return summary, nil
+}
+
+func (e *Engine) prepareSummaryRequestPrompt(messages []Message) string {
+	var sb strings.Builder
+
+	if e.summary != "" {
+		sb.WriteString("Previous summary:\n")
+		sb.WriteString(e.summary)
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString("New turns:\n")
+	for _, message := range messages {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", message.Role, message.Content))
+	}
+
+	return sb.String()
+}
+
+func (e *Engine) prepareSummaryPrompt() string {
+	return fmt.Sprintf("Summary of the earlier conversation: %s", e.summary)
+}
+
+// getKeptTurnsStart returns the index of the first message of the last turns,
+// 0 when there are not more turns than kept
+func getKeptTurnsStart(messages []Message, turns int) int {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != RoleUser {
+			continue
+		}
+		turns--
+		if turns == 0 {
+			return i
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,113 @@
+package ai
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummary(t *testing.T) {
+	t.Run("SummarizeContext", testSummarizeContext)
+	t.Run("SummarizeContextUnderThreshold", testSummarizeContextUnderThreshold)
+	t.Run("SummaryPrompt", testSummaryPrompt)
+	t.Run("Session", testSession)
+}
+
+func testSummarizeContext(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  max_tokens: 100\n  context_window: 1000\ntools:\n  allowed: []\n")
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: "the user works on a go project"},
+		},
+	}
+
+	engine := NewEngineWithProvider(ChatEngineMode, cfg, provider)
+
+	turn := strings.Repeat("x", engine.getContextBudget()*charsPerToken/4)
+	engine.chatMessages = []Message{
+		{Role: RoleUser, Content: turn},
+		{Role: RoleAssistant, Content: "first"},
+		{Role: RoleUser, Content: turn},
+		{Role: RoleAssistant, Content: "second"},
+		{Role: RoleUser, Content: turn},
+		{Role: RoleAssistant, Content: "third"},
+		{Role: RoleUser, Content: "and now?"},
+	}
+
+	warnings := engine.summarizeContext(context.Background())
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "4 older messages summarized")
+
+	assert.Equal(t, "the user works on a go project", engine.GetSummary())
+	assert.Equal(t, []Message{
+		{Role: RoleUser, Content: turn},
+		{Role: RoleAssistant, Content: "third"},
+		{Role: RoleUser, Content: "and now?"},
+	}, engine.chatMessages)
+
+	require.Len(t, provider.requests, 1)
+	assert.Equal(t, summarySystemPrompt, provider.requests[0].Messages[0].Content)
+	assert.Contains(t, provider.requests[0].Messages[1].Content, "assistant: second")
+	assert.NotContains(t, provider.requests[0].Messages[1].Content, "third")
+}
+
+func testSummarizeContextUnderThreshold(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  max_tokens: 100\n  context_window: 1000\ntools:\n  allowed: []\n")
+
+	provider := &fakeProvider{}
+
+	engine := NewEngineWithProvider(ChatEngineMode, cfg, provider)
+	engine.chatMessages = []Message{
+		{Role: RoleUser, Content: "hello"},
+		{Role: RoleAssistant, Content: "hi"},
+		{Role: RoleUser, Content: "how are you?"},
+	}
+
+	assert.Empty(t, engine.summarizeContext(context.Background()))
+	assert.Empty(t, engine.GetSummary())
+	assert.Empty(t, provider.requests)
+}
+
+func testSummaryPrompt(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  max_tokens: 100\n  context_window: 1000\ntools:\n  allowed: []\n")
+
+	engine := NewEngineWithProvider(ChatEngineMode, cfg, &fakeProvider{})
+	engine.SetSummary("the user works on a go project")
+	engine.appendUserMessage("and now?")
+
+	messages := engine.prepareCompletionMessages()
+	require.Len(t, messages, 3)
+	assert.Equal(t, RoleSystem, messages[1].Role)
+	assert.Contains(t, messages[1].Content, "the user works on a go project")
+
+	engine.Reset()
+	assert.Empty(t, engine.GetSummary())
+}
+
+func testSession(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\ntools:\n  allowed: []\n")
+	path := filepath.Join(t.TempDir(), "session.json")
+
+	session, err := LoadSession(path)
+	require.NoError(t, err)
+	assert.Empty(t, session.Messages)
+
+	engine := NewEngineWithProvider(ChatEngineMode, cfg, &fakeProvider{})
+	engine.SetSummary("the user works on a go project")
+	engine.appendUserMessage("hello")
+	engine.appendAssistantMessage("hi")
+
+	require.NoError(t, SaveSession(path, engine.GetSession()))
+
+	session, err = LoadSession(path)
+	require.NoError(t, err)
+
+	resumed := NewEngineWithProvider(ChatEngineMode, cfg, &fakeProvider{}).SetSession(session)
+	assert.Equal(t, "the user works on a go project", resumed.GetSummary())
+	assert.Equal(t, engine.chatMessages, resumed.chatMessages)
+}
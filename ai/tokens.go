@@ -0,0 +1,209 @@
+package ai
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bmichalkiewicz/gogut/config"
+)
+
+const (
+	defaultContextWindow = 8192
+	messageTokenOverhead = 4
+	charsPerToken        = 4
+	pipeTruncatedMarker  = "\n[... truncated ...]\n"
+	// minContextBudget is kept for the pipe and the conversation even when the
+	// system prompt and the reply seem to fill the context window
+	minContextBudget = 512
+)
+
+// contextWindows maps model name prefixes on their context window, the longest prefix wins
+var contextWindows = map[string]int{
+	"gpt-4o":        128000,
+	"gpt-4-turbo":   128000,
+	"gpt-4.1":       1047576,
+	"gpt-4":         8192,
+	"gpt-3.5-turbo": 16385,
+	"o1":            200000,
+	"o3":            200000,
+	"claude":        200000,
+	"llama3":        8192,
+	"llama3.1":      131072,
+	"llama3.2":      131072,
+	"mistral":       32768,
+	"mixtral":       32768,
+	"qwen2.5":       32768,
+	"gemma":         8192,
+	"phi3":          4096,
+}
+
+// estimateTokens approximates the token count of a text, about 4 characters
+// per token for english text and code
+func estimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+func estimateMessagesTokens(messages []Message) int {
+	tokens := 0
+	for _, message := range messages {
+		tokens += messageTokenOverhead + estimateTokens(message.Content)
+		for _, call := range message.ToolCalls {
+			tokens += estimateTokens(call.Name) + estimateTokens(call.Arguments)
+		}
+	}
+
+	return tokens
+}
+
+func getModelContextWindow(model string) int {
+	model = strings.ToLower(model)
+	// ollama tags and provider prefixes, eg. llama3:8b or openai/gpt-4o
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+
+	window, length := defaultContextWindow, 0
+	for prefix, size := range contextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > length {
+			window, length = size, len(prefix)
+		}
+	}
+
+	return window
+}
+
+// truncateText keeps the head and the tail of a text so it fits in maxTokens
+func truncateText(text string, maxTokens int) string {
+	maxChars := maxTokens * charsPerToken
+	if len(text) <= maxChars {
+		return text
+	}
+
+	keep := maxChars - len(pipeTruncatedMarker)
+	if keep <= 0 {
+		return ""
+	}
+
+	head := keep / 2
+	tail := keep - head
+
+	return strings.ToValidUTF8(text[:head]+pipeTruncatedMarker+text[len(text)-tail:], "")
+}
+
+// getConfigContextWindow returns the configured window, the ollama num_ctx
+// option or the known window of the model, in this order
+func getConfigContextWindow(aiConfig config.AIConfig) int {
+	if window := aiConfig.GetContextWindow(); window > 0 {
+		return window
+	}
+	if aiConfig.GetProvider() == OllamaProviderName && aiConfig.GetOllamaConfig().GetNumCtx() > 0 {
+		return aiConfig.GetOllamaConfig().GetNumCtx()
+	}
+
+	return getModelContextWindow(aiConfig.GetModel())
+}
+
+// getContextWindow returns the context window of the backend being asked,
+// fallbacks included
+func (e *Engine) getContextWindow() int {
+	if e.backend >= len(e.backends) {
+		return getConfigContextWindow(e.config.GetAIConfig())
+	}
+
+	return e.backends[e.backend].window
+}
+
+// getAvailableTokens returns the tokens of the context window left once the
+// system prompt and the reply are accounted for, negative when they overflow it
+func (e *Engine) getAvailableTokens() int {
+	reply := e.config.GetAIConfig().GetMaxTokens()
+	system := messageTokenOverhead + estimateTokens(e.prepareSystemPrompt())
+	if e.mode == ChatEngineMode && e.summary != "" {
+		system += messageTokenOverhead + estimateTokens(e.prepareSummaryPrompt())
+	}
+
+	return e.getContextWindow() - reply - system
+}
+
+// getContextBudget returns the tokens available for the pipe and the
+// conversation, at least minContextBudget so the input is never dropped
+func (e *Engine) getContextBudget() int {
+	return max(e.getAvailableTokens(), minContextBudget)
+}
+
+func (e *Engine) getPipeBudget() int {
+	return e.getContextBudget() / 2
+}
+
+// fitContext trims the oldest turns of the conversation and the piped input
+// so the next request fits in the context window, returning a warning for each
+func (e *Engine) fitContext() []string {
+	var warnings []string
+
+	if available := e.getAvailableTokens(); available < minContextBudget {
+		warnings = append(warnings, fmt.Sprintf(
+			"the system prompt and max_tokens leave ~%d tokens of the ~%d tokens context window, ~%d tokens are used anyway, raise context_window or lower max_tokens",
+			available,
+			e.getContextWindow(),
+			minContextBudget,
+		))
+	}
+
+	budget := e.getContextBudget()
+
+	if e.pipe != "" {
+		if tokens := estimateTokens(e.pipe); tokens > e.getPipeBudget() {
+			warnings = append(warnings, fmt.Sprintf(
+				"piped input truncated from ~%d to ~%d tokens to fit the context window",
+				tokens,
+				e.getPipeBudget(),
+			))
+		}
+		budget -= messageTokenOverhead + estimateTokens(e.preparePipePrompt())
+	}
+
+	if dropped := e.trimMessages(budget); dropped > 0 {
+		warnings = append(warnings, fmt.Sprintf(
+			"%d older messages dropped to fit the context window",
+			dropped,
+		))
+	}
+
+	return warnings
+}
+
+// trimMessages drops whole turns from the start of the current conversation
+// until it fits in budget, always keeping the last turn
+func (e *Engine) trimMessages(budget int) int {
+	messages := e.getMessages()
+
+	dropped := 0
+	for estimateMessagesTokens(messages[dropped:]) > budget {
+		next := dropped + 1
+		for next < len(messages) && messages[next].Role != RoleUser {
+			next++
+		}
+		if next >= len(messages) {
+			break
+		}
+		dropped = next
+	}
+
+	if dropped > 0 {
+		e.setMessages(messages[dropped:])
+	}
+
+	return dropped
+}
+
+// appendWarnings appends the warnings not reported yet
+func appendWarnings(warnings []string, others ...string) []string {
+	for _, other := range others {
+		if !slices.Contains(warnings, other) {
+			warnings = append(warnings, other)
+		}
+	}
+
+	return warnings
+}
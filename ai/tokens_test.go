@@ -0,0 +1,176 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokens(t *testing.T) {
+	t.Run("EstimateTokens", testEstimateTokens)
+	t.Run("GetModelContextWindow", testGetModelContextWindow)
+	t.Run("TruncateText", testTruncateText)
+	t.Run("TrimMessages", testTrimMessages)
+	t.Run("TruncatePipe", testTruncatePipe)
+	t.Run("MinContextBudget", testMinContextBudget)
+	t.Run("FallbackContextWindow", testFallbackContextWindow)
+}
+
+func testEstimateTokens(t *testing.T) {
+	assert.Equal(t, 0, estimateTokens(""))
+	assert.Equal(t, 1, estimateTokens("ls"))
+	assert.Equal(t, 2, estimateTokens("ls -la"))
+	assert.Equal(t, 2*messageTokenOverhead+2, estimateMessagesTokens([]Message{
+		{Role: RoleUser, Content: "ls"},
+		{Role: RoleAssistant, Content: "pwd"},
+	}))
+}
+
+func testGetModelContextWindow(t *testing.T) {
+	assert.Equal(t, 128000, getModelContextWindow("gpt-4o-mini"))
+	assert.Equal(t, 8192, getModelContextWindow("gpt-4"))
+	assert.Equal(t, 131072, getModelContextWindow("llama3.1:8b"))
+	assert.Equal(t, 8192, getModelContextWindow("llama3:8b"))
+	assert.Equal(t, 200000, getModelContextWindow("anthropic/claude-3-5-sonnet-latest"))
+	assert.Equal(t, defaultContextWindow, getModelContextWindow("unknown"))
+}
+
+func testTruncateText(t *testing.T) {
+	assert.Equal(t, "short", truncateText("short", 10))
+
+	text := strings.Repeat("a", 500) + strings.Repeat("b", 500)
+	truncated := truncateText(text, 50)
+
+	assert.LessOrEqual(t, len(truncated), 50*charsPerToken)
+	assert.True(t, strings.HasPrefix(truncated, "aaa"))
+	assert.True(t, strings.HasSuffix(truncated, "bbb"))
+	assert.Contains(t, truncated, pipeTruncatedMarker)
+}
+
+func testTrimMessages(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  max_tokens: 100\n  context_window: 1000\ntools:\n  allowed: []\n")
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: `{"cmd":"ls", "exp": "list files", "exec": true}`},
+		},
+	}
+
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, provider)
+
+	// each old turn takes about a third of the budget, only the last one fits next to the new input
+	turn := strings.Repeat("x", engine.getContextBudget()*charsPerToken/3)
+	engine.execMessages = []Message{
+		{Role: RoleUser, Content: turn},
+		{Role: RoleAssistant, Content: "first"},
+		{Role: RoleUser, Content: turn},
+		{Role: RoleAssistant, Content: "second"},
+		{Role: RoleUser, Content: turn},
+		{Role: RoleAssistant, Content: "third"},
+	}
+
+	output, err := engine.ExecCompletion(context.Background(), turn)
+	require.NoError(t, err)
+
+	require.Len(t, output.GetWarnings(), 1)
+	assert.Contains(t, output.GetWarnings()[0], "4 older messages dropped")
+
+	require.Len(t, provider.requests, 1)
+	messages := provider.requests[0].Messages
+	require.Len(t, messages, 4)
+	assert.Equal(t, RoleSystem, messages[0].Role)
+	assert.Equal(t, "third", messages[2].Content)
+	assert.Equal(t, turn, messages[3].Content)
+}
+
+func testTruncatePipe(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  max_tokens: 100\n  context_window: 1000\ntools:\n  allowed: []\n")
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: `{"cmd":"", "exp": "a log", "exec": false}`},
+		},
+	}
+
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, provider)
+	engine.SetPipe(strings.Repeat("log line\n", 1000))
+
+	output, err := engine.ExecCompletion(context.Background(), "what is this")
+	require.NoError(t, err)
+
+	require.Len(t, output.GetWarnings(), 1)
+	assert.Contains(t, output.GetWarnings()[0], "piped input truncated")
+
+	messages := provider.requests[0].Messages
+	require.Len(t, messages, 3)
+	assert.Contains(t, messages[1].Content, pipeTruncatedMarker)
+	assert.LessOrEqual(t, estimateMessagesTokens(messages), 1000-100)
+}
+
+func testMinContextBudget(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  max_tokens: 990\n  context_window: 1000\ntools:\n  allowed: []\n")
+
+	provider := &fakeProvider{
+		responses: []*Response{
+			{Content: `{"cmd":"", "exp": "a log", "exec": false}`},
+		},
+	}
+
+	engine := NewEngineWithProvider(ExecEngineMode, cfg, provider)
+	engine.SetPipe(strings.Repeat("log line\n", 1000))
+	assert.Less(t, engine.getAvailableTokens(), 0)
+	assert.Equal(t, minContextBudget, engine.getContextBudget())
+
+	output, err := engine.ExecCompletion(context.Background(), "what is this")
+	require.NoError(t, err)
+
+	warnings := output.GetWarnings()
+	require.Len(t, warnings, 2)
+	assert.Contains(t, warnings[0], "raise context_window or lower max_tokens")
+	assert.Contains(t, warnings[1], "piped input truncated")
+
+	// the piped input is truncated, never dropped
+	messages := provider.requests[0].Messages
+	require.Len(t, messages, 3)
+	assert.Contains(t, messages[1].Content, "log line")
+	assert.Contains(t, messages[1].Content, pipeTruncatedMarker)
+}
+
+func testFallbackContextWindow(t *testing.T) {
+	cfg := newTestConfig(t, "settings:\n  model: test_model\n  max_tokens: 100\n  retries: 0\n  timeout: 0s\ntools:\n  allowed: []\n")
+
+	primary, calls := newScriptedServer(t, http.StatusUnauthorized)
+	fallback := &fakeProvider{
+		responses: []*Response{
+			{Content: `{"cmd":"", "exp": "a log", "exec": false}`},
+		},
+	}
+
+	fallbackBackend := NewBackend("openai/fallback", "small", fallback)
+	fallbackBackend.window = 1000
+
+	engine := NewEngineWithBackends(ExecEngineMode, cfg, []Backend{
+		NewBackend("ollama/primary", "gpt-4.1", NewOllamaProvider("", primary.URL, 0, "", nil)),
+		fallbackBackend,
+	})
+	engine.SetPipe(strings.Repeat("log line\n", 1000))
+
+	output, err := engine.ExecCompletion(context.Background(), "what is this")
+	require.NoError(t, err)
+	assert.Equal(t, "openai/fallback", output.GetBackend())
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	// the primary window fits the whole input, the fallback one does not
+	require.Len(t, output.GetWarnings(), 1)
+	assert.Contains(t, output.GetWarnings()[0], "piped input truncated")
+
+	messages := fallback.requests[0].Messages
+	assert.Contains(t, messages[1].Content, pipeTruncatedMarker)
+	assert.LessOrEqual(t, estimateMessagesTokens(messages), 1000-100)
+	assert.Equal(t, 0, engine.backend)
+}
@@ -0,0 +1,407 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bmichalkiewicz/gogut/facts"
+	"github.com/mitchellh/go-homedir"
+)
+
+const (
+	ListDirectoryTool = "list_directory"
+	ReadFileTool      = "read_file"
+	WhichTool         = "which"
+	HelpTool          = "help"
+	EnvTool           = "env"
+)
+
+const (
+	maxToolRounds      = 5
+	maxToolOutput      = 4096
+	maxDirEntries      = 200
+	defaultReadLines   = 20
+	maxReadLines       = 100
+	helpCommandTimeout = 5 * time.Second
+)
+
+// sensitiveEnvMarkers are substrings of env variable names whose values are never sent to the model
+var sensitiveEnvMarkers = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL"}
+
+// sensitiveHomePaths are the files and directories of the home directory
+// holding credentials, they are never read nor listed, along with the gogut config
+var sensitiveHomePaths = []string{
+	".ssh",
+	".gnupg",
+	".aws",
+	".azure",
+	".config/gcloud",
+	".kube",
+	".docker",
+	".config/gh",
+	".password-store",
+	".netrc",
+	".git-credentials",
+	".pgpass",
+	".npmrc",
+	".pypirc",
+	".vault-token",
+}
+
+// sensitiveSystemPaths are the system files holding credentials
+var sensitiveSystemPaths = []string{"/etc/shadow", "/etc/gshadow", "/etc/sudoers", "/etc/sudoers.d", "/etc/ssh"}
+
+// sensitiveFileNames are the names of files holding secrets wherever they are,
+// .env also covers its variants like .env.local
+var sensitiveFileNames = []string{".env", "id_rsa", "id_dsa", "id_ecdsa", "id_ed25519"}
+
+// sensitiveExtensions are the extensions of keys and key stores
+var sensitiveExtensions = []string{".pem", ".key", ".p12", ".pfx", ".kdbx"}
+
+// defaultHelpBinaries are the binaries the help tool may run with --help,
+// unknown binaries could do anything else when given this flag
+var defaultHelpBinaries = []string{
+	"awk", "aws", "az", "cargo", "cat", "chmod", "chown", "cp", "curl", "cut", "date", "df", "docker",
+	"du", "ffmpeg", "find", "gcloud", "gh", "git", "go", "grep", "gzip", "head", "helm", "jq", "journalctl",
+	"kubectl", "ln", "ls", "make", "mkdir", "mv", "npm", "pip", "pip3", "ps", "rg", "rm", "rsync", "scp",
+	"sed", "sort", "ssh", "systemctl", "tail", "tar", "terraform", "tr", "uniq", "unzip", "wc", "wget",
+	"xargs", "yarn", "yq", "zip",
+}
+
+type tool struct {
+	definition ToolDefinition
+	run        func(t *Toolbox, args map[string]interface{}) (string, error)
+}
+
+var builtinTools = map[string]tool{
+	ListDirectoryTool: {
+		definition: ToolDefinition{
+			Name:        ListDirectoryTool,
+			Description: "List the entries of a directory on the user machine, directories are suffixed with /",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"path":{"type":"string","description":"directory path, ~ is expanded"}},"required":["path"]}`),
+		},
+		run: (*Toolbox).runListDirectory,
+	},
+	ReadFileTool: {
+		definition: ToolDefinition{
+			Name:        ReadFileTool,
+			Description: "Read the first lines of a file on the user machine",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"path":{"type":"string","description":"file path, ~ is expanded"},"lines":{"type":"integer","description":"number of lines to read, 20 by default, 100 at most"}},"required":["path"]}`),
+		},
+		run: (*Toolbox).runReadFile,
+	},
+	WhichTool: {
+		definition: ToolDefinition{
+			Name:        WhichTool,
+			Description: "Locate a binary in the user PATH, to check if a program is installed",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"binary":{"type":"string","description":"binary name"}},"required":["binary"]}`),
+		},
+		run: (*Toolbox).runWhich,
+	},
+	HelpTool: {
+		definition: ToolDefinition{
+			Name:        HelpTool,
+			Description: "Get the --help output of a common binary installed on the user machine, to check its available flags",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"binary":{"type":"string","description":"binary name"}},"required":["binary"]}`),
+		},
+		run: (*Toolbox).runHelp,
+	},
+	EnvTool: {
+		definition: ToolDefinition{
+			Name:        EnvTool,
+			Description: "Get the value of an environment variable of the user session",
+			Parameters:  json.RawMessage(`{"type":"object","properties":{"name":{"type":"string","description":"variable name"}},"required":["name"]}`),
+		},
+		run: (*Toolbox).runEnv,
+	},
+}
+
+// Toolbox holds the read only tools the model is allowed to call
+type Toolbox struct {
+	tools        map[string]tool
+	helpBinaries []string
+}
+
+func NewToolbox(allowed []string) *Toolbox {
+	tools := map[string]tool{}
+	for _, name := range allowed {
+		if t, ok := builtinTools[name]; ok {
+			tools[name] = t
+		}
+	}
+
+	return &Toolbox{
+		tools:        tools,
+		helpBinaries: defaultHelpBinaries,
+	}
+}
+
+// SetHelpBinaries sets the binaries the help tool may run, the default ones when empty
+func (t *Toolbox) SetHelpBinaries(binaries []string) *Toolbox {
+	if len(binaries) == 0 {
+		binaries = defaultHelpBinaries
+	}
+	t.helpBinaries = binaries
+
+	return t
+}
+
+func (t *Toolbox) IsEmpty() bool {
+	return len(t.tools) == 0
+}
+
+func (t *Toolbox) GetDefinitions() []ToolDefinition {
+	definitions := make([]ToolDefinition, 0, len(t.tools))
+	for _, name := range []string{ListDirectoryTool, ReadFileTool, WhichTool, HelpTool, EnvTool} {
+		if tool, ok := t.tools[name]; ok {
+			definitions = append(definitions, tool.definition)
+		}
+	}
+
+	return definitions
+}
+
+// Call runs the requested tool, failures are returned as the tool output so
+// the model can recover from them
+func (t *Toolbox) Call(call ToolCall) EngineToolCall {
+	output := EngineToolCall{
+		name:      call.Name,
+		arguments: call.Arguments,
+	}
+
+	tool, ok := t.tools[call.Name]
+	if !ok {
+		output.output = fmt.Sprintf("error: tool %s is not allowed", call.Name)
+		output.failed = true
+		return output
+	}
+
+	args := map[string]interface{}{}
+	if call.Arguments != "" {
+		if err := json.Unmarshal([]byte(call.Arguments), &args); err != nil {
+			output.output = fmt.Sprintf("error: invalid arguments: %s", err)
+			output.failed = true
+			return output
+		}
+	}
+
+	result, err := tool.run(t, args)
+	if err != nil {
+		output.output = fmt.Sprintf("error: %s", err)
+		output.failed = true
+		return output
+	}
+
+	output.output = truncateToolOutput(result)
+
+	return output
+}
+
+func (t *Toolbox) runListDirectory(args map[string]interface{}) (string, error) {
+	path, err := getPathArgument(args, "path")
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i, entry := range entries {
+		if i == maxDirEntries {
+			sb.WriteString(fmt.Sprintf("... %d more entries\n", len(entries)-maxDirEntries))
+			break
+		}
+		sb.WriteString(entry.Name())
+		if entry.IsDir() {
+			sb.WriteString("/")
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+func (t *Toolbox) runReadFile(args map[string]interface{}) (string, error) {
+	path, err := getPathArgument(args, "path")
+	if err != nil {
+		return "", err
+	}
+
+	lines := defaultReadLines
+	if value, ok := args["lines"].(float64); ok && value > 0 {
+		lines = int(value)
+	}
+	if lines > maxReadLines {
+		lines = maxReadLines
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var sb strings.Builder
+	scanner := bufio.NewScanner(file)
+	for i := 0; i < lines && scanner.Scan(); i++ {
+		sb.WriteString(scanner.Text())
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), scanner.Err()
+}
+
+func (t *Toolbox) runWhich(args map[string]interface{}) (string, error) {
+	binary, err := getBinaryArgument(args)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return fmt.Sprintf("%s not found", binary), nil
+	}
+
+	return path, nil
+}
+
+func (t *Toolbox) runHelp(args map[string]interface{}) (string, error) {
+	binary, err := getBinaryArgument(args)
+	if err != nil {
+		return "", err
+	}
+	if !slices.Contains(t.helpBinaries, binary) {
+		return "", fmt.Errorf("%s is not among the binaries allowed for %s: %s", binary, HelpTool, strings.Join(t.helpBinaries, ", "))
+	}
+
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return "", fmt.Errorf("%s not found", binary)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), helpCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "--help")
+	// most binaries exit with a non zero code on --help, the output still matters
+	out, _ := cmd.CombinedOutput()
+
+	return string(out), nil
+}
+
+func (t *Toolbox) runEnv(args map[string]interface{}) (string, error) {
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return "", errors.New("missing name argument")
+	}
+
+	upper := strings.ToUpper(name)
+	for _, marker := range sensitiveEnvMarkers {
+		if strings.Contains(upper, marker) {
+			return "", fmt.Errorf("%s may hold a secret and cannot be read", name)
+		}
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return fmt.Sprintf("%s is not set", name), nil
+	}
+
+	return value, nil
+}
+
+func getPathArgument(args map[string]interface{}, name string) (string, error) {
+	path, ok := args[name].(string)
+	if !ok || path == "" {
+		return "", fmt.Errorf("missing %s argument", name)
+	}
+
+	expanded, err := homedir.Expand(path)
+	if err != nil {
+		return "", err
+	}
+	path = filepath.Clean(expanded)
+
+	if isSensitivePath(path) {
+		return "", fmt.Errorf("%s may hold secrets and cannot be read", path)
+	}
+
+	// links are followed, a link to a sensitive path is refused as well
+	if resolved, err := filepath.EvalSymlinks(path); err == nil && isSensitivePath(resolved) {
+		return "", fmt.Errorf("%s may hold secrets and cannot be read", path)
+	}
+
+	return path, nil
+}
+
+// isSensitivePath tells if a path is or is within a file or directory holding credentials
+func isSensitivePath(path string) bool {
+	if !filepath.IsAbs(path) {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+	}
+
+	sensitive := append([]string{facts.GetConfigPath()}, sensitiveSystemPaths...)
+	if home := facts.GetHomeDirectory(); home != "" {
+		for _, p := range sensitiveHomePaths {
+			sensitive = append(sensitive, filepath.Join(home, p))
+		}
+	}
+
+	for _, p := range sensitive {
+		if isWithin(path, p) {
+			return true
+		}
+	}
+
+	for _, element := range strings.Split(path, string(filepath.Separator)) {
+		if element == ".env" || strings.HasPrefix(element, ".env.") || slices.Contains(sensitiveFileNames, element) {
+			return true
+		}
+	}
+
+	return slices.Contains(sensitiveExtensions, strings.ToLower(filepath.Ext(path)))
+}
+
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func getBinaryArgument(args map[string]interface{}) (string, error) {
+	binary, ok := args["binary"].(string)
+	if !ok || binary == "" {
+		return "", errors.New("missing binary argument")
+	}
+
+	if strings.ContainsAny(binary, "/\\ \t;|&$`") {
+		return "", fmt.Errorf("invalid binary name %q", binary)
+	}
+
+	return binary, nil
+}
+
+func truncateToolOutput(output string) string {
+	if len(output) <= maxToolOutput {
+		return output
+	}
+
+	return output[:maxToolOutput] + "\n[truncated]"
+}
@@ -0,0 +1,141 @@
+package ai
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestToolbox(t *testing.T) {
+	t.Run("Allowlist", testToolboxAllowlist)
+	t.Run("ListDirectory", testToolboxListDirectory)
+	t.Run("ReadFile", testToolboxReadFile)
+	t.Run("SensitivePaths", testToolboxSensitivePaths)
+	t.Run("Which", testToolboxWhich)
+	t.Run("Help", testToolboxHelp)
+	t.Run("Env", testToolboxEnv)
+	t.Run("InvalidArguments", testToolboxInvalidArguments)
+}
+
+func testToolboxAllowlist(t *testing.T) {
+	toolbox := NewToolbox([]string{EnvTool, "rm", WhichTool})
+
+	definitions := toolbox.GetDefinitions()
+	require.Len(t, definitions, 2)
+	assert.Equal(t, WhichTool, definitions[0].Name)
+	assert.Equal(t, EnvTool, definitions[1].Name)
+
+	call := toolbox.Call(ToolCall{Name: ReadFileTool, Arguments: `{"path":"/etc/passwd"}`})
+	assert.True(t, call.HasFailed())
+	assert.Contains(t, call.GetOutput(), "not allowed")
+
+	assert.True(t, NewToolbox(nil).IsEmpty())
+}
+
+func testToolboxListDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.txt"), []byte("content"), 0644))
+
+	toolbox := NewToolbox([]string{ListDirectoryTool})
+	call := toolbox.Call(ToolCall{Name: ListDirectoryTool, Arguments: `{"path":"` + dir + `"}`})
+
+	assert.False(t, call.HasFailed())
+	assert.Equal(t, "file.txt\nsub/\n", call.GetOutput())
+}
+
+func testToolboxReadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+	require.NoError(t, os.WriteFile(path, []byte("one\ntwo\nthree\n"), 0644))
+
+	toolbox := NewToolbox([]string{ReadFileTool})
+	call := toolbox.Call(ToolCall{Name: ReadFileTool, Arguments: `{"path":"` + path + `","lines":2}`})
+
+	assert.False(t, call.HasFailed())
+	assert.Equal(t, "one\ntwo\n", call.GetOutput())
+
+	call = toolbox.Call(ToolCall{Name: ReadFileTool, Arguments: `{"path":"` + path + `.missing"}`})
+	assert.True(t, call.HasFailed())
+}
+
+func testToolboxSensitivePaths(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".env"), []byte("TOKEN=secret"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, ".env"), filepath.Join(dir, "notes.txt")))
+
+	toolbox := NewToolbox([]string{ListDirectoryTool, ReadFileTool})
+
+	for _, path := range []string{
+		"~/.ssh/id_ed25519",
+		"~/.aws/credentials",
+		"~/.gogut/config.yaml",
+		"/etc/shadow",
+		filepath.Join(dir, ".env"),
+		filepath.Join(dir, "notes.txt"),
+		filepath.Join(dir, "server.key"),
+	} {
+		call := toolbox.Call(ToolCall{Name: ReadFileTool, Arguments: `{"path":"` + path + `"}`})
+		assert.True(t, call.HasFailed(), path)
+		assert.Contains(t, call.GetOutput(), "may hold secrets", path)
+	}
+
+	call := toolbox.Call(ToolCall{Name: ListDirectoryTool, Arguments: `{"path":"~/.ssh"}`})
+	assert.True(t, call.HasFailed())
+	assert.Contains(t, call.GetOutput(), "may hold secrets")
+
+	call = toolbox.Call(ToolCall{Name: ListDirectoryTool, Arguments: `{"path":"` + dir + `"}`})
+	assert.False(t, call.HasFailed())
+}
+
+func testToolboxWhich(t *testing.T) {
+	toolbox := NewToolbox([]string{WhichTool})
+
+	call := toolbox.Call(ToolCall{Name: WhichTool, Arguments: `{"binary":"surely-not-installed-binary"}`})
+	assert.False(t, call.HasFailed())
+	assert.Equal(t, "surely-not-installed-binary not found", call.GetOutput())
+
+	call = toolbox.Call(ToolCall{Name: WhichTool, Arguments: `{"binary":"ls; rm -rf /"}`})
+	assert.True(t, call.HasFailed())
+}
+
+func testToolboxHelp(t *testing.T) {
+	toolbox := NewToolbox([]string{HelpTool})
+
+	call := toolbox.Call(ToolCall{Name: HelpTool, Arguments: `{"binary":"surely-not-installed-binary"}`})
+	assert.True(t, call.HasFailed())
+	assert.Contains(t, call.GetOutput(), "not among the binaries allowed")
+
+	toolbox.SetHelpBinaries([]string{"go"})
+	call = toolbox.Call(ToolCall{Name: HelpTool, Arguments: `{"binary":"git"}`})
+	assert.True(t, call.HasFailed())
+
+	call = toolbox.Call(ToolCall{Name: HelpTool, Arguments: `{"binary":"go"}`})
+	assert.False(t, call.HasFailed())
+	assert.Contains(t, call.GetOutput(), "go")
+}
+
+func testToolboxEnv(t *testing.T) {
+	t.Setenv("GOGUT_TEST_VALUE", "value")
+	t.Setenv("GOGUT_TEST_TOKEN", "secret")
+
+	toolbox := NewToolbox([]string{EnvTool})
+
+	call := toolbox.Call(ToolCall{Name: EnvTool, Arguments: `{"name":"GOGUT_TEST_VALUE"}`})
+	assert.False(t, call.HasFailed())
+	assert.Equal(t, "value", call.GetOutput())
+
+	call = toolbox.Call(ToolCall{Name: EnvTool, Arguments: `{"name":"GOGUT_TEST_TOKEN"}`})
+	assert.True(t, call.HasFailed())
+	assert.Contains(t, call.GetOutput(), "may hold a secret")
+}
+
+func testToolboxInvalidArguments(t *testing.T) {
+	toolbox := NewToolbox([]string{EnvTool})
+
+	call := toolbox.Call(ToolCall{Name: EnvTool, Arguments: `{"name":`})
+	assert.True(t, call.HasFailed())
+	assert.Contains(t, call.GetOutput(), "invalid arguments")
+}
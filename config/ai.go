@@ -1,20 +1,58 @@
 package config
 
+import "time"
+
 // OpenAI Compabilities
 const (
+	commonProvider    = "settings.provider"
 	commonKey         = "settings.key"
 	commonModel       = "settings.model"
 	commonURL         = "settings.url"
 	commonTemperature = "settings.temperature"
 	commonMaxTokens   = "settings.max_tokens"
+
+	commonContextWindow = "settings.context_window"
+
+	commonStructuredOutput = "settings.structured_output"
+	commonTimeout          = "settings.timeout"
+	commonConnectTimeout   = "settings.connect_timeout"
+	commonRetries          = "settings.retries"
+	commonRetryBackoff     = "settings.retry_backoff"
+	commonFallbacks        = "settings.fallbacks"
+	// commonShell overrides the shell detected from $SHELL
+	commonShell = "settings.shell"
+)
+
+// keys of each settings.fallbacks entry, missing ones are inherited from settings
+const (
+	fallbackProvider = "provider"
+	fallbackKey      = "key"
+	fallbackModel    = "model"
+	fallbackURL      = "url"
+	fallbackWindow   = "context_window"
 )
 
 type AIConfig struct {
+	provider    string
 	key         string
 	model       string
 	url         string
 	temperature float64
 	maxTokens   int
+	window      int
+	structured  bool
+	timeout     time.Duration
+	connect     time.Duration
+	retries     int
+	backoff     time.Duration
+	fallbacks   []AIConfig
+	ollama      OllamaConfig
+	anthropic   AnthropicConfig
+	sampling    map[string]SamplingConfig
+}
+
+func (c AIConfig) GetProvider() string {
+	return c.provider
 }
 
 func (c AIConfig) GetKey() string {
@@ -36,3 +74,89 @@ func (c AIConfig) GetTemperature() float64 {
 func (c AIConfig) GetMaxTokens() int {
 	return c.maxTokens
 }
+
+// GetContextWindow returns the configured context window of the model, 0 when unset
+func (c AIConfig) GetContextWindow() int {
+	return c.window
+}
+
+// IsStructuredOutput reports if JSON schema response formats should be requested
+// from backends supporting them
+func (c AIConfig) IsStructuredOutput() bool {
+	return c.structured
+}
+
+// GetTimeout returns the maximum duration of a whole request, streaming included
+func (c AIConfig) GetTimeout() time.Duration {
+	return c.timeout
+}
+
+func (c AIConfig) GetConnectTimeout() time.Duration {
+	return c.connect
+}
+
+// GetRetries returns how many times a failed request is retried on each backend
+func (c AIConfig) GetRetries() int {
+	return c.retries
+}
+
+// GetRetryBackoff returns the base delay of the exponential backoff between retries
+func (c AIConfig) GetRetryBackoff() time.Duration {
+	return c.backoff
+}
+
+// GetFallbacks returns the ordered backends tried when this one fails
+func (c AIConfig) GetFallbacks() []AIConfig {
+	return c.fallbacks
+}
+
+func (c AIConfig) GetOllamaConfig() OllamaConfig {
+	return c.ollama
+}
+
+func (c AIConfig) GetAnthropicConfig() AnthropicConfig {
+	return c.anthropic
+}
+
+// GetSamplingConfig returns the sampling parameters for the given prompt mode,
+// falling back on the common settings
+func (c AIConfig) GetSamplingConfig(mode string) SamplingConfig {
+	if sampling, ok := c.sampling[mode]; ok {
+		return sampling
+	}
+
+	return c.sampling[""]
+}
+
+func loadFallbacks(common AIConfig) []AIConfig {
+	var fallbacks []AIConfig
+
+	for _, entry := range config.Slices(commonFallbacks) {
+		fallback := common
+		fallback.fallbacks = nil
+
+		if entry.Exists(fallbackProvider) {
+			fallback.provider = entry.String(fallbackProvider)
+		}
+		if entry.Exists(fallbackKey) {
+			fallback.key = entry.String(fallbackKey)
+		}
+		if entry.Exists(fallbackModel) {
+			fallback.model = entry.String(fallbackModel)
+			// the configured window is the one of the primary model
+			if fallback.model != common.model {
+				fallback.window = 0
+			}
+		}
+		if entry.Exists(fallbackWindow) {
+			fallback.window = entry.Int(fallbackWindow)
+		}
+		if entry.Exists(fallbackURL) {
+			fallback.url = entry.String(fallbackURL)
+		}
+
+		fallbacks = append(fallbacks, fallback)
+	}
+
+	return fallbacks
+}
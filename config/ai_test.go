@@ -7,10 +7,21 @@ import (
 )
 
 func TestAiConfig(t *testing.T) {
+	t.Run("GetProvider", testGetProvider)
 	t.Run("GetKey", testGetKey)
 	t.Run("GetProxy", testGetProxy)
 	t.Run("GetTemperature", testGetTemperature)
 	t.Run("GetMaxTokens", testGetMaxTokens)
+	t.Run("GetContextWindow", testGetContextWindow)
+}
+
+func testGetProvider(t *testing.T) {
+	expectedProvider := "ollama"
+	aiConfig := AIConfig{provider: expectedProvider}
+
+	actualProvider := aiConfig.GetProvider()
+
+	assert.Equal(t, expectedProvider, actualProvider, "The two providers should be the same.")
 }
 
 func testGetKey(t *testing.T) {
@@ -48,3 +59,12 @@ func testGetMaxTokens(t *testing.T) {
 
 	assert.Equal(t, expectedMaxTokens, actualMaxTokens, "The two maxTokens should be the same.")
 }
+
+func testGetContextWindow(t *testing.T) {
+	expectedWindow := 32768
+	aiConfig := AIConfig{window: expectedWindow}
+
+	actualWindow := aiConfig.GetContextWindow()
+
+	assert.Equal(t, expectedWindow, actualWindow, "The two context windows should be the same.")
+}
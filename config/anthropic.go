@@ -0,0 +1,25 @@
+package config
+
+const (
+	anthropicKey     = "settings.anthropic.key"
+	anthropicURL     = "settings.anthropic.url"
+	anthropicVersion = "settings.anthropic.version"
+)
+
+type AnthropicConfig struct {
+	key     string
+	url     string
+	version string
+}
+
+func (c AnthropicConfig) GetKey() string {
+	return c.key
+}
+
+func (c AnthropicConfig) GetURL() string {
+	return c.url
+}
+
+func (c AnthropicConfig) GetVersion() string {
+	return c.version
+}
@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+const (
+	cacheEnabled = "cache.enabled"
+	cacheTTL     = "cache.ttl"
+)
+
+type CacheConfig struct {
+	enabled bool
+	ttl     time.Duration
+}
+
+// IsEnabled tells if exec answers are cached on disk
+func (c CacheConfig) IsEnabled() bool {
+	return c.enabled
+}
+
+// GetTTL returns how long a cached answer is served
+func (c CacheConfig) GetTTL() time.Duration {
+	return c.ttl
+}
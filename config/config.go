@@ -19,9 +19,14 @@ var (
 )
 
 type Config struct {
-	common AIConfig
-	user   UserConfig
-	facts  *facts.Analysis
+	common  AIConfig
+	user    UserConfig
+	tools   ToolsConfig
+	history HistoryConfig
+	cache   CacheConfig
+	safety  SafetyConfig
+	fix     FixConfig
+	facts   *facts.Analysis
 }
 
 func (c *Config) GetAIConfig() AIConfig {
@@ -32,6 +37,26 @@ func (c *Config) GetUserConfig() UserConfig {
 	return c.user
 }
 
+func (c *Config) GetToolsConfig() ToolsConfig {
+	return c.tools
+}
+
+func (c *Config) GetHistoryConfig() HistoryConfig {
+	return c.history
+}
+
+func (c *Config) GetCacheConfig() CacheConfig {
+	return c.cache
+}
+
+func (c *Config) GetSafetyConfig() SafetyConfig {
+	return c.safety
+}
+
+func (c *Config) GetFixConfig() FixConfig {
+	return c.fix
+}
+
 func (c *Config) GetSystemConfig() *facts.Analysis {
 	return c.facts
 }
@@ -51,18 +76,59 @@ func NewConfig(configFile string) (*Config, error) {
 		return nil, ConfigFileNotfoundError{}
 	}
 
-	return &Config{
-		common: AIConfig{
-			key:         config.String(commonKey),
-			model:       config.String(commonModel),
-			url:         config.String(commonURL),
-			temperature: config.Float64(commonTemperature),
-			maxTokens:   config.Int(commonMaxTokens),
+	if shell := config.String(commonShell); shell != "" {
+		facts.SetShell(shell)
+	}
+
+	common := AIConfig{
+		provider:    config.String(commonProvider),
+		key:         config.String(commonKey),
+		model:       config.String(commonModel),
+		url:         config.String(commonURL),
+		temperature: config.Float64(commonTemperature),
+		maxTokens:   config.Int(commonMaxTokens),
+		window:      config.Int(commonContextWindow),
+		structured:  !config.Exists(commonStructuredOutput) || config.Bool(commonStructuredOutput),
+		timeout:     config.Duration(commonTimeout),
+		connect:     config.Duration(commonConnectTimeout),
+		retries:     config.Int(commonRetries),
+		backoff:     config.Duration(commonRetryBackoff),
+		ollama: OllamaConfig{
+			numCtx:    config.Int(ollamaNumCtx),
+			keepAlive: config.String(ollamaKeepAlive),
+		},
+		anthropic: AnthropicConfig{
+			key:     config.String(anthropicKey),
+			url:     config.String(anthropicURL),
+			version: config.String(anthropicVersion),
 		},
+		sampling: loadSamplingConfigs(),
+	}
+	common.fallbacks = loadFallbacks(common)
+
+	return &Config{
+		common: common,
 		user: UserConfig{
 			defaultPromptMode: config.String(userDefaultPromptMode),
 			preferences:       config.String(userPreferences),
 		},
+		tools: ToolsConfig{
+			allowed:      config.Strings(toolsAllowed),
+			helpBinaries: config.Strings(toolsHelpBinaries),
+		},
+		history: HistoryConfig{
+			maxSize: config.Int(historyMaxSize),
+		},
+		cache: CacheConfig{
+			enabled: config.Bool(cacheEnabled),
+			ttl:     config.Duration(cacheTTL),
+		},
+		safety: SafetyConfig{
+			rules: loadSafetyRules(),
+		},
+		fix: FixConfig{
+			maxAttempts: config.Int(fixMaxAttempts),
+		},
 		facts: facts,
 	}, nil
 }
@@ -72,12 +138,22 @@ func WriteConfig(APIKey, configFile string, save bool) (*Config, error) {
 
 	// openai defaults
 	defaults := map[string]interface{}{
-		commonURL:             "",
-		commonTemperature:     0.2,
-		commonMaxTokens:       1000,
-		commonModel:           "",
-		userDefaultPromptMode: "exec",
-		userPreferences:       "",
+		commonProvider:         "openai",
+		commonURL:              "",
+		commonTemperature:      0.2,
+		commonMaxTokens:        1000,
+		commonStructuredOutput: true,
+		commonTimeout:          "2m",
+		commonConnectTimeout:   "10s",
+		commonRetries:          2,
+		commonRetryBackoff:     "1s",
+		commonModel:            "",
+		userDefaultPromptMode:  "exec",
+		userPreferences:        "",
+		historyMaxSize:         1000,
+		cacheEnabled:           true,
+		cacheTTL:               "24h",
+		fixMaxAttempts:         3,
 	}
 
 	err := config.Set(commonKey, APIKey)
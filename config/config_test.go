@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
 	"github.com/stretchr/testify/assert"
@@ -12,12 +13,16 @@ import (
 func TestConfig(t *testing.T) {
 	t.Run("NewConfig", testNewConfig)
 	t.Run("WriteConfig", testWriteConfig)
+	t.Run("LoadPolicyConfig", testLoadPolicyConfig)
 }
 
 func setupConfig(t *testing.T) {
 	t.Helper()
 
-	err := config.Set(commonKey, "test_key")
+	err := config.Set(commonProvider, "ollama")
+	require.NoError(t, err)
+
+	err = config.Set(commonKey, "test_key")
 	require.NoError(t, err)
 
 	err = config.Set(commonModel, openai.GPT3Dot5Turbo)
@@ -32,12 +37,83 @@ func setupConfig(t *testing.T) {
 	err = config.Set(commonMaxTokens, 2000)
 	require.NoError(t, err)
 
+	err = config.Set(commonTimeout, "30s")
+	require.NoError(t, err)
+
+	err = config.Set(commonConnectTimeout, "5s")
+	require.NoError(t, err)
+
+	err = config.Set(commonRetries, 3)
+	require.NoError(t, err)
+
+	err = config.Set(commonRetryBackoff, "500ms")
+	require.NoError(t, err)
+
+	err = config.Set(commonFallbacks, []map[string]interface{}{
+		{fallbackProvider: "openai", fallbackModel: "gpt-4o", fallbackURL: ""},
+		{fallbackModel: "gpt-4o-mini", fallbackWindow: 64000},
+	})
+	require.NoError(t, err)
+
+	err = config.Set(commonContextWindow, 16385)
+	require.NoError(t, err)
+
+	err = config.Set("settings.top_p", 0.9)
+	require.NoError(t, err)
+
+	err = config.Set("settings.stop", []string{"END"})
+	require.NoError(t, err)
+
+	err = config.Set("exec.temperature", 0)
+	require.NoError(t, err)
+
+	err = config.Set("exec.seed", 42)
+	require.NoError(t, err)
+
+	err = config.Set("chat.temperature", 0.7)
+	require.NoError(t, err)
+
+	err = config.Set(ollamaNumCtx, 8192)
+	require.NoError(t, err)
+
+	err = config.Set(ollamaKeepAlive, "10m")
+	require.NoError(t, err)
+
+	err = config.Set(anthropicKey, "test_anthropic_key")
+	require.NoError(t, err)
+
+	err = config.Set(anthropicURL, "test_anthropic_url")
+	require.NoError(t, err)
+
+	err = config.Set(anthropicVersion, "2023-06-01")
+	require.NoError(t, err)
+
 	err = config.Set(userDefaultPromptMode, "exec")
 	require.NoError(t, err)
 
 	err = config.Set(userPreferences, "test_preferences")
 	require.NoError(t, err)
 
+	err = config.Set(historyMaxSize, 500)
+	require.NoError(t, err)
+
+	err = config.Set(cacheEnabled, true)
+	require.NoError(t, err)
+
+	err = config.Set(cacheTTL, "1h")
+	require.NoError(t, err)
+
+	err = config.Set(safetyRules, []map[string]interface{}{
+		{safetyRuleName: "terraform-destroy", safetyRulePattern: `terraform\s+destroy`, safetyRuleDescription: "destroys the infrastructure"},
+	})
+	require.NoError(t, err)
+
+	err = config.Set(fixMaxAttempts, 2)
+	require.NoError(t, err)
+
+	err = config.Set(commonShell, "fish")
+	require.NoError(t, err)
+
 	bytes, err := config.Marshal(parser)
 	require.NoError(t, err)
 	require.NoError(t, os.WriteFile("/tmp/config.yaml", bytes, 0644))
@@ -54,15 +130,71 @@ func testNewConfig(t *testing.T) {
 	cfg, err := NewConfig("/tmp/config.yaml")
 	require.NoError(t, err)
 
+	assert.Equal(t, "ollama", cfg.GetAIConfig().GetProvider())
 	assert.Equal(t, "test_key", cfg.GetAIConfig().GetKey())
 	assert.Equal(t, openai.GPT3Dot5Turbo, cfg.GetAIConfig().GetModel())
 	assert.Equal(t, "test_url", cfg.GetAIConfig().GetURL())
 	assert.Equal(t, 0.2, cfg.GetAIConfig().GetTemperature())
 	assert.Equal(t, 2000, cfg.GetAIConfig().GetMaxTokens())
+	assert.True(t, cfg.GetAIConfig().IsStructuredOutput())
+	assert.Equal(t, 30*time.Second, cfg.GetAIConfig().GetTimeout())
+	assert.Equal(t, 5*time.Second, cfg.GetAIConfig().GetConnectTimeout())
+	assert.Equal(t, 3, cfg.GetAIConfig().GetRetries())
+	assert.Equal(t, 500*time.Millisecond, cfg.GetAIConfig().GetRetryBackoff())
+
+	fallbacks := cfg.GetAIConfig().GetFallbacks()
+	require.Len(t, fallbacks, 2)
+	assert.Equal(t, "openai", fallbacks[0].GetProvider())
+	assert.Equal(t, "gpt-4o", fallbacks[0].GetModel())
+	assert.Equal(t, "", fallbacks[0].GetURL())
+	assert.Equal(t, "test_key", fallbacks[0].GetKey())
+	assert.Empty(t, fallbacks[0].GetFallbacks())
+	// the window of the primary model is not inherited by other models
+	assert.Equal(t, 16385, cfg.GetAIConfig().GetContextWindow())
+	assert.Equal(t, 0, fallbacks[0].GetContextWindow())
+	assert.Equal(t, 64000, fallbacks[1].GetContextWindow())
+	assert.Equal(t, 8192, cfg.GetAIConfig().GetOllamaConfig().GetNumCtx())
+	assert.Equal(t, "10m", cfg.GetAIConfig().GetOllamaConfig().GetKeepAlive())
+	assert.Equal(t, "test_anthropic_key", cfg.GetAIConfig().GetAnthropicConfig().GetKey())
+	assert.Equal(t, "test_anthropic_url", cfg.GetAIConfig().GetAnthropicConfig().GetURL())
+	assert.Equal(t, "2023-06-01", cfg.GetAIConfig().GetAnthropicConfig().GetVersion())
 	assert.Equal(t, "exec", cfg.GetUserConfig().GetDefaultPromptMode())
 	assert.Equal(t, "test_preferences", cfg.GetUserConfig().GetPreferences())
+	assert.Equal(t, 500, cfg.GetHistoryConfig().GetMaxSize())
+	assert.True(t, cfg.GetCacheConfig().IsEnabled())
+	assert.Equal(t, time.Hour, cfg.GetCacheConfig().GetTTL())
+	assert.Equal(t, 2, cfg.GetFixConfig().GetMaxAttempts())
+
+	rules := cfg.GetSafetyConfig().GetRules()
+	require.Len(t, rules, 1)
+	assert.Equal(t, "terraform-destroy", rules[0].GetName())
+	assert.Equal(t, `terraform\s+destroy`, rules[0].GetPattern())
+	assert.Equal(t, "destroys the infrastructure", rules[0].GetDescription())
+
+	common := cfg.GetAIConfig().GetSamplingConfig("")
+	require.NotNil(t, common.GetTemperature())
+	assert.Equal(t, 0.2, *common.GetTemperature())
+	require.NotNil(t, common.GetTopP())
+	assert.Equal(t, 0.9, *common.GetTopP())
+	assert.Equal(t, []string{"END"}, common.GetStop())
+	assert.Nil(t, common.GetSeed())
+	assert.Nil(t, common.GetPresencePenalty())
+
+	exec := cfg.GetAIConfig().GetSamplingConfig("exec")
+	require.NotNil(t, exec.GetTemperature())
+	assert.Equal(t, 0.0, *exec.GetTemperature())
+	require.NotNil(t, exec.GetSeed())
+	assert.Equal(t, 42, *exec.GetSeed())
+	require.NotNil(t, exec.GetTopP())
+	assert.Equal(t, 0.9, *exec.GetTopP())
+
+	chat := cfg.GetAIConfig().GetSamplingConfig("chat")
+	require.NotNil(t, chat.GetTemperature())
+	assert.Equal(t, 0.7, *chat.GetTemperature())
+	assert.Nil(t, chat.GetSeed())
 
 	assert.NotNil(t, cfg.GetSystemConfig())
+	assert.Equal(t, "fish", cfg.GetSystemConfig().GetShell())
 
 }
 
@@ -92,3 +224,23 @@ func testWriteConfig(t *testing.T) {
 	assert.Equal(t, "test_preferences", config.Get(userPreferences))
 
 }
+
+func testLoadPolicyConfig(t *testing.T) {
+	policy, err := LoadPolicyConfig("/tmp/missing_policy.yaml")
+	require.NoError(t, err)
+	assert.Empty(t, policy.GetPrefixes())
+	assert.Empty(t, policy.GetPatterns())
+
+	content := "allow:\n  prefixes:\n    - git status\n    - ls\n  patterns:\n    - 'docker (ps|images)'\n"
+	require.NoError(t, os.WriteFile("/tmp/policy.yaml", []byte(content), 0644))
+	defer os.Remove("/tmp/policy.yaml")
+
+	policy, err = LoadPolicyConfig("/tmp/policy.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"git status", "ls"}, policy.GetPrefixes())
+	assert.Equal(t, []string{"docker (ps|images)"}, policy.GetPatterns())
+
+	require.NoError(t, os.WriteFile("/tmp/policy.yaml", []byte("allow: [\n"), 0644))
+	_, err = LoadPolicyConfig("/tmp/policy.yaml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,15 @@
+package config
+
+const (
+	fixMaxAttempts = "fix.max_attempts"
+)
+
+type FixConfig struct {
+	maxAttempts int
+}
+
+// GetMaxAttempts returns how many times in a row gogut may be asked to fix a
+// failing command, 0 for the default
+func (c FixConfig) GetMaxAttempts() int {
+	return c.maxAttempts
+}
@@ -0,0 +1,14 @@
+package config
+
+const (
+	historyMaxSize = "history.max_size"
+)
+
+type HistoryConfig struct {
+	maxSize int
+}
+
+// GetMaxSize returns the number of inputs kept on disk per prompt mode, 0 for the default
+func (c HistoryConfig) GetMaxSize() int {
+	return c.maxSize
+}
@@ -0,0 +1,19 @@
+package config
+
+const (
+	ollamaNumCtx    = "settings.ollama.num_ctx"
+	ollamaKeepAlive = "settings.ollama.keep_alive"
+)
+
+type OllamaConfig struct {
+	numCtx    int
+	keepAlive string
+}
+
+func (c OllamaConfig) GetNumCtx() int {
+	return c.numCtx
+}
+
+func (c OllamaConfig) GetKeepAlive() string {
+	return c.keepAlive
+}
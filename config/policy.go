@@ -0,0 +1,49 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+const (
+	policyAllowPrefixes = "allow.prefixes"
+	policyAllowPatterns = "allow.patterns"
+)
+
+// PolicyConfig lists the commands allowed to run without confirmation with --yes,
+// read from its own file so it can be shared or versioned apart from the settings
+type PolicyConfig struct {
+	prefixes []string
+	patterns []string
+}
+
+// GetPrefixes returns the allowed command prefixes, eg. git status
+func (c PolicyConfig) GetPrefixes() []string {
+	return c.prefixes
+}
+
+// GetPatterns returns the regular expressions of the allowed commands
+func (c PolicyConfig) GetPatterns() []string {
+	return c.patterns
+}
+
+// LoadPolicyConfig reads the policy file, a missing file allows nothing
+func LoadPolicyConfig(policyFile string) (PolicyConfig, error) {
+	if _, err := os.Stat(policyFile); errors.Is(err, os.ErrNotExist) {
+		return PolicyConfig{}, nil
+	}
+
+	policy := koanf.New(".")
+	if err := policy.Load(file.Provider(policyFile), parser); err != nil {
+		return PolicyConfig{}, fmt.Errorf("failed to load policy from %s: %v", policyFile, err)
+	}
+
+	return PolicyConfig{
+		prefixes: policy.Strings(policyAllowPrefixes),
+		patterns: policy.Strings(policyAllowPatterns),
+	}, nil
+}
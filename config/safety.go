@@ -0,0 +1,51 @@
+package config
+
+const (
+	safetyRules = "safety.rules"
+
+	safetyRuleName        = "name"
+	safetyRulePattern     = "pattern"
+	safetyRuleDescription = "description"
+)
+
+type SafetyConfig struct {
+	rules []SafetyRule
+}
+
+// GetRules returns the user defined dangerous command rules, added to the built in ones
+func (c SafetyConfig) GetRules() []SafetyRule {
+	return c.rules
+}
+
+// SafetyRule flags the commands matching a regular expression as dangerous
+type SafetyRule struct {
+	name        string
+	pattern     string
+	description string
+}
+
+func (r SafetyRule) GetName() string {
+	return r.name
+}
+
+func (r SafetyRule) GetPattern() string {
+	return r.pattern
+}
+
+func (r SafetyRule) GetDescription() string {
+	return r.description
+}
+
+func loadSafetyRules() []SafetyRule {
+	var rules []SafetyRule
+
+	for _, entry := range config.Slices(safetyRules) {
+		rules = append(rules, SafetyRule{
+			name:        entry.String(safetyRuleName),
+			pattern:     entry.String(safetyRulePattern),
+			description: entry.String(safetyRuleDescription),
+		})
+	}
+
+	return rules
+}
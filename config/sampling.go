@@ -0,0 +1,98 @@
+package config
+
+import "fmt"
+
+const (
+	samplingTemperature      = "temperature"
+	samplingTopP             = "top_p"
+	samplingSeed             = "seed"
+	samplingStop             = "stop"
+	samplingPresencePenalty  = "presence_penalty"
+	samplingFrequencyPenalty = "frequency_penalty"
+)
+
+// samplingModes are the prompt modes accepting per mode overrides, eg. exec.temperature
+var samplingModes = []string{"exec", "chat", "explain"}
+
+type SamplingConfig struct {
+	temperature      *float64
+	topP             *float64
+	seed             *int
+	stop             []string
+	presencePenalty  *float64
+	frequencyPenalty *float64
+}
+
+func (c SamplingConfig) GetTemperature() *float64 {
+	return c.temperature
+}
+
+func (c SamplingConfig) GetTopP() *float64 {
+	return c.topP
+}
+
+func (c SamplingConfig) GetSeed() *int {
+	return c.seed
+}
+
+func (c SamplingConfig) GetStop() []string {
+	return c.stop
+}
+
+func (c SamplingConfig) GetPresencePenalty() *float64 {
+	return c.presencePenalty
+}
+
+func (c SamplingConfig) GetFrequencyPenalty() *float64 {
+	return c.frequencyPenalty
+}
+
+// loadSamplingConfig reads the sampling keys under each prefix, later prefixes
+// overriding earlier ones
+func loadSamplingConfig(prefixes ...string) SamplingConfig {
+	var sampling SamplingConfig
+
+	for _, prefix := range prefixes {
+		key := func(name string) string {
+			return fmt.Sprintf("%s.%s", prefix, name)
+		}
+
+		if config.Exists(key(samplingTemperature)) {
+			value := config.Float64(key(samplingTemperature))
+			sampling.temperature = &value
+		}
+		if config.Exists(key(samplingTopP)) {
+			value := config.Float64(key(samplingTopP))
+			sampling.topP = &value
+		}
+		if config.Exists(key(samplingSeed)) {
+			value := config.Int(key(samplingSeed))
+			sampling.seed = &value
+		}
+		if config.Exists(key(samplingStop)) {
+			sampling.stop = config.Strings(key(samplingStop))
+		}
+		if config.Exists(key(samplingPresencePenalty)) {
+			value := config.Float64(key(samplingPresencePenalty))
+			sampling.presencePenalty = &value
+		}
+		if config.Exists(key(samplingFrequencyPenalty)) {
+			value := config.Float64(key(samplingFrequencyPenalty))
+			sampling.frequencyPenalty = &value
+		}
+	}
+
+	return sampling
+}
+
+func loadSamplingConfigs() map[string]SamplingConfig {
+	samplings := map[string]SamplingConfig{
+		"": loadSamplingConfig("settings"),
+	}
+
+	for _, mode := range samplingModes {
+		samplings[mode] = loadSamplingConfig("settings", mode)
+	}
+
+	return samplings
+}
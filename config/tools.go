@@ -0,0 +1,21 @@
+package config
+
+const (
+	toolsAllowed      = "tools.allowed"
+	toolsHelpBinaries = "tools.help_binaries"
+)
+
+type ToolsConfig struct {
+	allowed      []string
+	helpBinaries []string
+}
+
+// GetAllowed returns the names of the tools the model is allowed to call
+func (c ToolsConfig) GetAllowed() []string {
+	return c.allowed
+}
+
+// GetHelpBinaries returns the binaries the help tool may run with --help, empty for the default ones
+func (c ToolsConfig) GetHelpBinaries() []string {
+	return c.helpBinaries
+}
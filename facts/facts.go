@@ -1,6 +1,8 @@
 package facts
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"runtime"
@@ -39,6 +41,13 @@ func (a *Analysis) GetShell() string {
 	return a.shell
 }
 
+// SetShell overrides the detected shell
+func (a *Analysis) SetShell(shell string) *Analysis {
+	a.shell = shell
+
+	return a
+}
+
 func (a *Analysis) GetHomeDirectory() string {
 	return a.homeDirectory
 }
@@ -136,6 +145,51 @@ func GetConfigFile() string {
 	)
 }
 
+// GetHistoryFile returns the history file of a prompt mode, eg. exec or chat
+func GetHistoryFile(mode string) string {
+	return fmt.Sprintf(
+		"%s/history_%s",
+		GetConfigPath(),
+		mode,
+	)
+}
+
+// GetCommandsFile returns the file logging the executed commands
+func GetCommandsFile() string {
+	return fmt.Sprintf(
+		"%s/commands",
+		GetConfigPath(),
+	)
+}
+
+// GetCacheDir returns the directory of the cached answers
+func GetCacheDir() string {
+	return fmt.Sprintf(
+		"%s/cache",
+		GetConfigPath(),
+	)
+}
+
+// GetPolicyFile returns the file listing the commands allowed to run without confirmation
+func GetPolicyFile() string {
+	return fmt.Sprintf(
+		"%s/policy.yaml",
+		GetConfigPath(),
+	)
+}
+
+// GetSessionFile returns the chat session file of a working directory, so the
+// chats of different projects do not overwrite each other
+func GetSessionFile(dir string) string {
+	hash := sha256.Sum256([]byte(dir))
+
+	return fmt.Sprintf(
+		"%s/sessions/%s.json",
+		GetConfigPath(),
+		hex.EncodeToString(hash[:8]),
+	)
+}
+
 func GetConfigPath() string {
 	return fmt.Sprintf(
 		"%s/.%s",
@@ -5,6 +5,7 @@ go 1.21.7
 require (
 	github.com/charmbracelet/bubbletea v0.26.1
 	github.com/stretchr/testify v1.9.0
+	mvdan.cc/sh/v3 v3.7.0
 )
 
 require (
@@ -64,11 +65,11 @@ require (
 	github.com/muesli/reflow v0.3.0 // indirect
 	github.com/muesli/termenv v0.15.2 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	github.com/sashabaranov/go-openai v1.23.0
+	github.com/sashabaranov/go-openai v1.29.2
 	github.com/spf13/pflag v1.0.5
 	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842 // indirect
 	golang.org/x/sync v0.7.0 // indirect
-	golang.org/x/sys v0.20.0 // indirect
+	golang.org/x/sys v0.20.0
 	golang.org/x/term v0.20.0 // indirect
 	golang.org/x/text v0.15.0 // indirect
 )
@@ -0,0 +1,222 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Command is an executed command along with the prompt which produced it and its outcome
+type Command struct {
+	prompt    string
+	command   string
+	cwd       string
+	timestamp time.Time
+	exitCode  int
+	duration  time.Duration
+}
+
+func NewCommand(prompt, command, cwd string, timestamp time.Time, exitCode int, duration time.Duration) Command {
+	return Command{
+		prompt:    prompt,
+		command:   command,
+		cwd:       cwd,
+		timestamp: timestamp,
+		exitCode:  exitCode,
+		duration:  duration,
+	}
+}
+
+func (c Command) GetPrompt() string {
+	return c.prompt
+}
+
+func (c Command) GetCommand() string {
+	return c.command
+}
+
+func (c Command) GetCwd() string {
+	return c.cwd
+}
+
+func (c Command) GetTimestamp() time.Time {
+	return c.timestamp
+}
+
+func (c Command) GetExitCode() int {
+	return c.exitCode
+}
+
+func (c Command) GetDuration() time.Duration {
+	return c.duration
+}
+
+type commandRecord struct {
+	Prompt    string    `json:"prompt"`
+	Command   string    `json:"command"`
+	Cwd       string    `json:"cwd"`
+	Timestamp time.Time `json:"timestamp"`
+	ExitCode  int       `json:"exit_code"`
+	Duration  int64     `json:"duration_ms"`
+}
+
+// CommandLog stores the executed commands in an append only file shared by every gogut process
+type CommandLog struct {
+	file    string
+	maxSize int
+}
+
+func NewCommandLog(file string, maxSize int) *CommandLog {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	return &CommandLog{
+		file:    file,
+		maxSize: maxSize,
+	}
+}
+
+// Add appends a command to the log, the oldest commands are dropped past the max size
+func (l *CommandLog) Add(command Command) error {
+	line, err := json.Marshal(commandRecord{
+		Prompt:    command.prompt,
+		Command:   command.command,
+		Cwd:       command.cwd,
+		Timestamp: command.timestamp,
+		ExitCode:  command.exitCode,
+		Duration:  command.duration.Milliseconds(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return withLockedFile(l.file, os.O_RDWR|os.O_CREATE, func(f *os.File) error {
+		lines, err := readLines(f)
+		if err != nil {
+			return err
+		}
+
+		lines = append(lines, string(line))
+		if len(lines) <= l.maxSize {
+			_, err := f.Write(append(line, '\n'))
+			return err
+		}
+
+		return writeLines(f, lines[len(lines)-l.maxSize:])
+	})
+}
+
+// GetAll returns the logged commands, most recent first
+func (l *CommandLog) GetAll() ([]Command, error) {
+	var commands []Command
+
+	err := withLockedFile(l.file, os.O_RDONLY|os.O_CREATE, func(f *os.File) error {
+		lines, err := readLines(f)
+		if err != nil {
+			return err
+		}
+
+		for i := len(lines) - 1; i >= 0; i-- {
+			var record commandRecord
+			if err := json.Unmarshal([]byte(lines[i]), &record); err != nil {
+				continue
+			}
+			commands = append(commands, NewCommand(
+				record.Prompt,
+				record.Command,
+				record.Cwd,
+				record.Timestamp,
+				record.ExitCode,
+				time.Duration(record.Duration)*time.Millisecond,
+			))
+		}
+
+		return nil
+	})
+
+	return commands, err
+}
+
+// Search returns the logged commands whose prompt, command or directory have
+// words starting with the terms of the query, the ones matching the most terms
+// first and most recent first for equal matches, stop words are ignored and
+// relative dates like yesterday or last week restrict the time range
+func (l *CommandLog) Search(query string) ([]Command, error) {
+	return l.search(query, time.Now())
+}
+
+func (l *CommandLog) search(query string, now time.Time) ([]Command, error) {
+	commands, err := l.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	q := parseCommandQuery(query, now)
+
+	type scoredCommand struct {
+		command Command
+		score   int
+	}
+
+	var matches []scoredCommand
+	for _, command := range commands {
+		if !q.includes(command.timestamp) {
+			continue
+		}
+		if len(q.terms) == 0 {
+			matches = append(matches, scoredCommand{command: command})
+			continue
+		}
+
+		score := q.score(strings.Join([]string{command.prompt, command.command, command.cwd}, " "))
+		if score > 0 {
+			matches = append(matches, scoredCommand{command: command, score: score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	results := make([]Command, 0, len(matches))
+	for _, match := range matches {
+		results = append(results, match.command)
+	}
+
+	return results, nil
+}
+
+func readLines(f *os.File) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+func writeLines(f *os.File, lines []string) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, line := range lines {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,106 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandLog(t *testing.T) {
+	t.Run("AddGetAll", func(t *testing.T) {
+		log := NewCommandLog(filepath.Join(t.TempDir(), "commands"), 10)
+
+		commands, err := log.GetAll()
+		require.NoError(t, err)
+		assert.Empty(t, commands)
+
+		timestamp := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+		require.NoError(t, log.Add(NewCommand("list files", "ls -la", "/tmp", timestamp, 0, 1500*time.Millisecond)))
+		require.NoError(t, log.Add(NewCommand("show disk usage", "df -h", "/home", timestamp.Add(time.Hour), 1, time.Second)))
+
+		commands, err = log.GetAll()
+		require.NoError(t, err)
+		require.Len(t, commands, 2)
+
+		assert.Equal(t, "df -h", commands[0].GetCommand())
+		assert.Equal(t, "show disk usage", commands[0].GetPrompt())
+		assert.Equal(t, "/home", commands[0].GetCwd())
+		assert.Equal(t, 1, commands[0].GetExitCode())
+		assert.Equal(t, time.Second, commands[0].GetDuration())
+		assert.True(t, timestamp.Add(time.Hour).Equal(commands[0].GetTimestamp()))
+		assert.Equal(t, "ls -la", commands[1].GetCommand())
+	})
+
+	t.Run("MaxSize", func(t *testing.T) {
+		log := NewCommandLog(filepath.Join(t.TempDir(), "commands"), 3)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, log.Add(NewCommand("", fmt.Sprintf("echo %d", i), "", time.Now(), 0, 0)))
+		}
+
+		commands, err := log.GetAll()
+		require.NoError(t, err)
+		require.Len(t, commands, 3)
+		assert.Equal(t, "echo 4", commands[0].GetCommand())
+		assert.Equal(t, "echo 2", commands[2].GetCommand())
+	})
+
+	t.Run("Search", func(t *testing.T) {
+		log := NewCommandLog(filepath.Join(t.TempDir(), "commands"), 10)
+
+		require.NoError(t, log.Add(NewCommand("rotate the certs", "certbot renew --force-renewal", "/etc/nginx", time.Now(), 0, 0)))
+		require.NoError(t, log.Add(NewCommand("list files", "ls -la", "/tmp", time.Now(), 0, 0)))
+		require.NoError(t, log.Add(NewCommand("check certs expiry", "openssl x509 -enddate -noout -in cert.pem", "/etc/nginx", time.Now(), 0, 0)))
+
+		commands, err := log.Search("rotate the certs")
+		require.NoError(t, err)
+		require.Len(t, commands, 2)
+		assert.Equal(t, "certbot renew --force-renewal", commands[0].GetCommand())
+		assert.Equal(t, "openssl x509 -enddate -noout -in cert.pem", commands[1].GetCommand())
+
+		commands, err = log.Search("NGINX")
+		require.NoError(t, err)
+		assert.Len(t, commands, 2)
+
+		commands, err = log.Search("")
+		require.NoError(t, err)
+		assert.Len(t, commands, 3)
+
+		commands, err = log.Search("kubectl")
+		require.NoError(t, err)
+		assert.Empty(t, commands)
+
+		// stop words and parts of words do not match
+		commands, err = log.Search("what was the command I ran to list files")
+		require.NoError(t, err)
+		require.Len(t, commands, 1)
+		assert.Equal(t, "ls -la", commands[0].GetCommand())
+	})
+
+	t.Run("SearchDates", func(t *testing.T) {
+		log := NewCommandLog(filepath.Join(t.TempDir(), "commands"), 10)
+
+		now := time.Date(2024, 5, 15, 15, 30, 0, 0, time.UTC)
+		require.NoError(t, log.Add(NewCommand("rotate the certs", "certbot renew", "/etc/nginx", now.AddDate(0, 0, -20), 0, 0)))
+		require.NoError(t, log.Add(NewCommand("rotate the certs", "certbot renew --force-renewal", "/etc/nginx", now.AddDate(0, 0, -4), 0, 0)))
+		require.NoError(t, log.Add(NewCommand("list files", "ls -la", "/tmp", now.AddDate(0, 0, -1), 0, 0)))
+
+		commands, err := log.search("the command I ran to rotate the certs last week", now)
+		require.NoError(t, err)
+		require.Len(t, commands, 1)
+		assert.Equal(t, "certbot renew --force-renewal", commands[0].GetCommand())
+
+		commands, err = log.search("yesterday", now)
+		require.NoError(t, err)
+		require.Len(t, commands, 1)
+		assert.Equal(t, "ls -la", commands[0].GetCommand())
+
+		commands, err = log.search("certs this month", now)
+		require.NoError(t, err)
+		assert.Len(t, commands, 1)
+	})
+}
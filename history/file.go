@@ -0,0 +1,144 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const DefaultMaxSize = 1000
+
+// NewFileHistory loads the history stored in file, new inputs are appended to
+// it so they outlive the process, at most maxSize inputs are kept
+func NewFileHistory(file string, maxSize int) (*History, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	h := NewHistory()
+	h.file = file
+	h.maxSize = maxSize
+
+	var inputs []string
+	err := withLockedFile(file, os.O_RDWR|os.O_CREATE, func(f *os.File) error {
+		var err error
+		inputs, err = readInputs(f)
+		if err != nil {
+			return err
+		}
+
+		if len(inputs) > maxSize {
+			inputs = inputs[len(inputs)-maxSize:]
+			return writeInputs(f, inputs)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, input := range inputs {
+		h.inputs[i] = input
+	}
+	h.cursor = len(inputs) - 1
+	h.stored = len(inputs)
+
+	return h, nil
+}
+
+// persist appends the input to the file, compacting it once it grew twice as
+// large as allowed, other processes may have appended meanwhile
+func (h *History) persist(input string) error {
+	line, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+
+	h.stored++
+	if h.stored <= 2*h.maxSize {
+		return withLockedFile(h.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, func(f *os.File) error {
+			_, err := f.Write(append(line, '\n'))
+			return err
+		})
+	}
+
+	return withLockedFile(h.file, os.O_RDWR|os.O_CREATE, func(f *os.File) error {
+		inputs, err := readInputs(f)
+		if err != nil {
+			return err
+		}
+
+		inputs = append(inputs, input)
+		if len(inputs) > h.maxSize {
+			inputs = inputs[len(inputs)-h.maxSize:]
+		}
+		h.stored = len(inputs)
+
+		return writeInputs(f, inputs)
+	})
+}
+
+func withLockedFile(file string, flag int, fn func(f *os.File) error) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, flag, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := lockFile(f); err != nil {
+		return err
+	}
+	defer unlockFile(f)
+
+	return fn(f)
+}
+
+// readInputs reads one JSON encoded input per line, broken lines are skipped
+func readInputs(f *os.File) ([]string, error) {
+	var inputs []string
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var input string
+		if err := json.Unmarshal([]byte(line), &input); err != nil {
+			continue
+		}
+		inputs = append(inputs, input)
+	}
+
+	return inputs, scanner.Err()
+}
+
+func writeInputs(f *os.File, inputs []string) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	for _, input := range inputs {
+		line, err := json.Marshal(input)
+		if err != nil {
+			return err
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,107 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileHistory(t *testing.T) {
+	t.Run("Persist", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "history_exec")
+
+		h, err := NewFileHistory(file, 10)
+		require.NoError(t, err)
+		h.Add("input1").Add("input2")
+		require.NoError(t, h.GetError())
+
+		h, err = NewFileHistory(file, 10)
+		require.NoError(t, err)
+		assert.Equal(t, map[int]string{0: "input1", 1: "input2"}, h.GetAll())
+
+		prev := h.GetPrevious()
+		require.NotNil(t, prev)
+		assert.Equal(t, "input2", *prev)
+	})
+
+	t.Run("Dedup", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "history_exec")
+
+		h, err := NewFileHistory(file, 10)
+		require.NoError(t, err)
+		h.Add("input1").Add("input1").Add("input2").Add("input1")
+		assert.Equal(t, 3, len(h.GetAll()))
+
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, "\"input1\"\n\"input2\"\n\"input1\"\n", string(content))
+	})
+
+	t.Run("MaxSize", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "history_exec")
+
+		h, err := NewFileHistory(file, 3)
+		require.NoError(t, err)
+		for i := 0; i < 10; i++ {
+			h.Add(fmt.Sprintf("input%d", i))
+		}
+
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, strings.Count(string(content), "\n"), 6)
+
+		h, err = NewFileHistory(file, 3)
+		require.NoError(t, err)
+		assert.Equal(t, map[int]string{0: "input7", 1: "input8", 2: "input9"}, h.GetAll())
+	})
+
+	t.Run("MultiLine", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "history_chat")
+
+		h, err := NewFileHistory(file, 10)
+		require.NoError(t, err)
+		h.Add("first line\nsecond line")
+
+		h, err = NewFileHistory(file, 10)
+		require.NoError(t, err)
+		assert.Equal(t, "first line\nsecond line", h.GetAll()[0])
+	})
+
+	t.Run("BrokenLines", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "history_exec")
+		require.NoError(t, os.WriteFile(file, []byte("\"input1\"\nnot json\n\"input2\"\n"), 0600))
+
+		h, err := NewFileHistory(file, 10)
+		require.NoError(t, err)
+		assert.Equal(t, map[int]string{0: "input1", 1: "input2"}, h.GetAll())
+	})
+
+	t.Run("Concurrent", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "history_exec")
+
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				h, err := NewFileHistory(file, 1000)
+				assert.NoError(t, err)
+				for j := 0; j < 25; j++ {
+					h.Add(fmt.Sprintf("process%d-input%d", i, j))
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		h, err := NewFileHistory(file, 1000)
+		require.NoError(t, err)
+		assert.Equal(t, 100, len(h.GetAll()))
+	})
+}
@@ -1,14 +1,18 @@
 package history
 
 type History struct {
-	inputs map[int]string
-	cursor int
+	inputs  map[int]string
+	cursor  int
+	file    string
+	maxSize int
+	stored  int
+	err     error
 }
 
 func NewHistory() *History {
 	return &History{
-		map[int]string{},
-		0,
+		inputs: map[int]string{},
+		cursor: 0,
 	}
 }
 
@@ -19,10 +23,22 @@ func (h *History) Reset() *History {
 	return h
 }
 
+// Add appends an input, an input equal to the previous one is not repeated
 func (h *History) Add(input string) *History {
+	h.err = nil
+
+	if last, ok := h.inputs[len(h.inputs)-1]; ok && last == input {
+		h.cursor = len(h.inputs) - 1
+		return h
+	}
+
 	h.cursor = len(h.inputs)
 	h.inputs[h.cursor] = input
 
+	if h.file != "" {
+		h.err = h.persist(input)
+	}
+
 	return h
 }
 
@@ -34,6 +50,11 @@ func (h *History) GetCursor() int {
 	return h.cursor
 }
 
+// GetError returns the error of the last write to the history file, if any
+func (h *History) GetError() error {
+	return h.err
+}
+
 func (h *History) GetPrevious() *string {
 	if input, ok := h.inputs[h.cursor]; ok {
 		h.cursor--
@@ -0,0 +1,185 @@
+package history
+
+import (
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// stopWords are ignored in command queries, they would match most commands
+var stopWords = map[string]bool{
+	"a": true, "about": true, "all": true, "an": true, "and": true, "any": true, "are": true, "as": true,
+	"at": true, "be": true, "by": true, "can": true, "command": true, "commands": true, "did": true,
+	"do": true, "does": true, "executed": true, "for": true, "from": true, "how": true, "i": true,
+	"in": true, "into": true, "is": true, "it": true, "me": true, "my": true, "of": true, "on": true,
+	"or": true, "ran": true, "run": true, "show": true, "that": true, "the": true, "then": true,
+	"there": true, "this": true, "to": true, "used": true, "was": true, "we": true, "were": true,
+	"what": true, "when": true, "where": true, "which": true, "with": true, "you": true,
+}
+
+// numberWords are the spelled numbers understood in relative dates, eg. two days ago
+var numberWords = map[string]int{
+	"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+	"seven": 7, "eight": 8, "nine": 9, "ten": 10,
+}
+
+// commandQuery is a command log query split in the meaningful terms and the
+// time range given by relative dates, zero bounds are open
+type commandQuery struct {
+	terms []string
+	since time.Time
+	until time.Time
+}
+
+// parseCommandQuery extracts from a query the relative dates, eg. yesterday,
+// last week or 3 days ago, and the terms left once stop words are dropped
+func parseCommandQuery(query string, now time.Time) commandQuery {
+	words := splitWords(query)
+
+	var q commandQuery
+	for i := 0; i < len(words); i++ {
+		since, until, n := parseRelativeDate(words[i:], now)
+		if n > 0 {
+			// since yesterday goes up to now
+			if i > 0 && words[i-1] == "since" {
+				until = time.Time{}
+			}
+			q.since, q.until = since, until
+			i += n - 1
+			continue
+		}
+
+		if !stopWords[words[i]] && words[i] != "since" {
+			q.terms = append(q.terms, words[i])
+		}
+	}
+
+	return q
+}
+
+// parseRelativeDate parses the relative date starting words and returns the
+// time range it covers and the number of words it takes, 0 when there is none
+func parseRelativeDate(words []string, now time.Time) (time.Time, time.Time, int) {
+	today := startOfDay(now)
+
+	switch words[0] {
+	case "today":
+		return today, time.Time{}, 1
+	case "yesterday":
+		return today.AddDate(0, 0, -1), today, 1
+	case "this":
+		if len(words) < 2 {
+			return time.Time{}, time.Time{}, 0
+		}
+		switch words[1] {
+		case "morning":
+			return today, time.Time{}, 2
+		case "week":
+			weekday := (int(now.Weekday()) + 6) % 7
+			return today.AddDate(0, 0, -weekday), time.Time{}, 2
+		case "month":
+			return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), time.Time{}, 2
+		case "year":
+			return time.Date(now.Year(), 1, 1, 0, 0, 0, 0, now.Location()), time.Time{}, 2
+		}
+	case "last", "past":
+		// last week is the last 7 days, last 3 days the last 3 days
+		if len(words) >= 2 {
+			if since, ok := subtractUnit(now, words[1], 1); ok {
+				return since, time.Time{}, 2
+			}
+		}
+		if len(words) >= 3 {
+			if count, ok := parseNumber(words[1]); ok {
+				if since, ok := subtractUnit(now, words[2], count); ok {
+					return since, time.Time{}, 3
+				}
+			}
+		}
+	default:
+		// 3 days ago is the whole day, 2 weeks ago the week ending a week ago
+		if len(words) >= 3 && words[2] == "ago" {
+			count, ok := parseNumber(words[0])
+			if !ok {
+				break
+			}
+			if strings.TrimSuffix(words[1], "s") == "day" {
+				day := today.AddDate(0, 0, -count)
+				return day, day.AddDate(0, 0, 1), 3
+			}
+			since, ok := subtractUnit(now, words[1], count)
+			if !ok {
+				break
+			}
+			until, _ := subtractUnit(now, words[1], count-1)
+			return since, until, 3
+		}
+	}
+
+	return time.Time{}, time.Time{}, 0
+}
+
+func subtractUnit(now time.Time, unit string, count int) (time.Time, bool) {
+	switch strings.TrimSuffix(unit, "s") {
+	case "hour":
+		return now.Add(-time.Duration(count) * time.Hour), true
+	case "day":
+		return now.AddDate(0, 0, -count), true
+	case "week":
+		return now.AddDate(0, 0, -7*count), true
+	case "month":
+		return now.AddDate(0, -count, 0), true
+	case "year":
+		return now.AddDate(-count, 0, 0), true
+	}
+
+	return time.Time{}, false
+}
+
+func parseNumber(word string) (int, bool) {
+	if count, ok := numberWords[word]; ok {
+		return count, true
+	}
+
+	count, err := strconv.Atoi(word)
+
+	return count, err == nil && count > 0
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// splitWords lowercases a text and splits it on anything but letters and digits
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// includes tells if a timestamp is within the time range of the query
+func (q commandQuery) includes(timestamp time.Time) bool {
+	if !q.since.IsZero() && timestamp.Before(q.since) {
+		return false
+	}
+
+	return q.until.IsZero() || timestamp.Before(q.until)
+}
+
+// score returns how many terms of the query start a word of the text
+func (q commandQuery) score(text string) int {
+	words := splitWords(text)
+
+	score := 0
+	for _, term := range q.terms {
+		for _, word := range words {
+			if strings.HasPrefix(word, term) {
+				score++
+				break
+			}
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,61 @@
+package history
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandQuery(t *testing.T) {
+	// a wednesday afternoon
+	now := time.Date(2024, 5, 15, 15, 30, 0, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	t.Run("Terms", func(t *testing.T) {
+		q := parseCommandQuery("What was the command I ran to rotate the certs in /etc/nginx?", now)
+		assert.Equal(t, []string{"rotate", "certs", "etc", "nginx"}, q.terms)
+		assert.True(t, q.since.IsZero())
+		assert.True(t, q.until.IsZero())
+
+		assert.Empty(t, parseCommandQuery("the command I ran", now).terms)
+	})
+
+	t.Run("RelativeDates", func(t *testing.T) {
+		ranges := map[string][2]time.Time{
+			"today":           {day(15), {}},
+			"yesterday":       {day(14), day(15)},
+			"since yesterday": {day(14), {}},
+			"this week":       {day(13), {}},
+			"this month":      {day(1), {}},
+			"last week":       {now.AddDate(0, 0, -7), {}},
+			"past 3 days":     {now.AddDate(0, 0, -3), {}},
+			"last two hours":  {now.Add(-2 * time.Hour), {}},
+			"last month":      {now.AddDate(0, -1, 0), {}},
+			"3 days ago":      {day(12), day(13)},
+			"a week ago":      {now.AddDate(0, 0, -7), now},
+			"2 weeks ago":     {now.AddDate(0, 0, -14), now.AddDate(0, 0, -7)},
+		}
+
+		for query, expected := range ranges {
+			q := parseCommandQuery("docker prune "+query, now)
+			assert.Equal(t, []string{"docker", "prune"}, q.terms, query)
+			assert.True(t, expected[0].Equal(q.since), "%s: since %s", query, q.since)
+			assert.True(t, expected[1].Equal(q.until), "%s: until %s", query, q.until)
+		}
+	})
+
+	t.Run("NotDates", func(t *testing.T) {
+		assert.Equal(t, []string{"last", "lines", "log"}, parseCommandQuery("last lines of the log", now).terms)
+		assert.Equal(t, []string{"3", "retries"}, parseCommandQuery("3 retries", now).terms)
+	})
+
+	t.Run("Score", func(t *testing.T) {
+		q := parseCommandQuery("ls certs", now)
+		assert.Equal(t, 1, q.score("renew the certs with certbot"))
+		assert.Equal(t, 0, q.score("false && tools"))
+		assert.Equal(t, 2, q.score("ls -la /etc/certs"))
+	})
+}
@@ -0,0 +1,196 @@
+package history
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	matchScore       = 1
+	consecutiveBonus = 4
+	wordStartBonus   = 3
+	substringBonus   = 10
+)
+
+// Match is a history input matching a search query
+type Match struct {
+	input     string
+	index     int
+	score     int
+	positions []int
+}
+
+func (m Match) GetInput() string {
+	return m.input
+}
+
+// GetIndex returns the position of the input in the history
+func (m Match) GetIndex() int {
+	return m.index
+}
+
+func (m Match) GetScore() int {
+	return m.score
+}
+
+// GetPositions returns the rune positions of the input matching the query
+func (m Match) GetPositions() []int {
+	return m.positions
+}
+
+// Search returns the inputs fuzzy matching the query, best matches first and
+// most recent first for equal scores, each input appears once
+func (h *History) Search(query string) []Match {
+	var matches []Match
+
+	seen := map[string]bool{}
+	for i := len(h.inputs) - 1; i >= 0; i-- {
+		input, ok := h.inputs[i]
+		if !ok || seen[input] {
+			continue
+		}
+		seen[input] = true
+
+		score, positions, ok := fuzzyMatch(input, query)
+		if !ok {
+			continue
+		}
+
+		matches = append(matches, Match{
+			input:     input,
+			index:     i,
+			score:     score,
+			positions: positions,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	return matches
+}
+
+// fuzzyMatch tells if the query runes appear in order in the input, ignoring
+// case, scoring consecutive runes, word starts and plain substrings higher
+func fuzzyMatch(input, query string) (int, []int, bool) {
+	if query == "" {
+		return 0, nil, true
+	}
+
+	runes := []rune(strings.ToLower(input))
+	queryRunes := []rune(strings.ToLower(query))
+
+	score := 0
+	positions := make([]int, 0, len(queryRunes))
+
+	// a plain substring is the best match, its positions are used as is
+	if start := strings.Index(string(runes), string(queryRunes)); start >= 0 {
+		offset := len([]rune(string(runes)[:start]))
+		for i := range queryRunes {
+			positions = append(positions, offset+i)
+		}
+		score = substringBonus + len(queryRunes)*(matchScore+consecutiveBonus)
+		if isWordStart(runes, offset) {
+			score += wordStartBonus
+		}
+
+		return score, positions, true
+	}
+
+	q := 0
+	for i := 0; i < len(runes) && q < len(queryRunes); i++ {
+		if runes[i] != queryRunes[q] {
+			continue
+		}
+
+		score += matchScore
+		if len(positions) > 0 && positions[len(positions)-1] == i-1 {
+			score += consecutiveBonus
+		}
+		if isWordStart(runes, i) {
+			score += wordStartBonus
+		}
+
+		positions = append(positions, i)
+		q++
+	}
+
+	if q < len(queryRunes) {
+		return 0, nil, false
+	}
+
+	return score, positions, true
+}
+
+func isWordStart(runes []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+
+	previous := runes[i-1]
+
+	return unicode.IsSpace(previous) || unicode.IsPunct(previous) || unicode.IsSymbol(previous)
+}
+
+// Search is a reverse incremental search over a history, as in readline
+type Search struct {
+	history *History
+	query   string
+	matches []Match
+	cursor  int
+}
+
+func NewSearch(history *History) *Search {
+	return &Search{
+		history: history,
+		query:   "",
+		matches: nil,
+		cursor:  0,
+	}
+}
+
+func (s *Search) GetQuery() string {
+	return s.query
+}
+
+// SetQuery updates the query and goes back to the best match
+func (s *Search) SetQuery(query string) *Search {
+	s.query = query
+	s.cursor = 0
+	s.matches = nil
+
+	if query != "" {
+		s.matches = s.history.Search(query)
+	}
+
+	return s
+}
+
+// Next moves on the next best match, staying on the last one
+func (s *Search) Next() *Search {
+	if s.cursor < len(s.matches)-1 {
+		s.cursor++
+	}
+
+	return s
+}
+
+// Previous moves back on the previous better match
+func (s *Search) Previous() *Search {
+	if s.cursor > 0 {
+		s.cursor--
+	}
+
+	return s
+}
+
+// GetMatch returns the current match, nil when nothing matches
+func (s *Search) GetMatch() *Match {
+	if s.cursor >= len(s.matches) {
+		return nil
+	}
+
+	return &s.matches[s.cursor]
+}
@@ -0,0 +1,77 @@
+package history
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearch(t *testing.T) {
+	t.Run("FuzzyMatch", func(t *testing.T) {
+		score, positions, ok := fuzzyMatch("docker ps -a", "dps")
+		assert.True(t, ok)
+		assert.Equal(t, []int{0, 7, 8}, positions)
+		assert.Greater(t, score, 0)
+
+		_, _, ok = fuzzyMatch("docker ps -a", "psd")
+		assert.False(t, ok)
+
+		_, positions, ok = fuzzyMatch("Git Status", "status")
+		assert.True(t, ok)
+		assert.Equal(t, []int{4, 5, 6, 7, 8, 9}, positions)
+	})
+
+	t.Run("SubstringFirst", func(t *testing.T) {
+		substring, _, _ := fuzzyMatch("list certs", "cert")
+		scattered, _, _ := fuzzyMatch("create a new rotation", "cert")
+		assert.Greater(t, substring, scattered)
+	})
+
+	t.Run("HistorySearch", func(t *testing.T) {
+		h := NewHistory()
+		h.Add("git status").Add("docker ps").Add("git stash").Add("git status").Add("ls")
+
+		matches := h.Search("gst")
+		require.Len(t, matches, 2)
+		assert.Equal(t, "git status", matches[0].GetInput())
+		assert.Equal(t, 3, matches[0].GetIndex())
+		assert.Equal(t, "git stash", matches[1].GetInput())
+
+		assert.Empty(t, h.Search("kubectl"))
+	})
+
+	t.Run("MostRecentFirst", func(t *testing.T) {
+		h := NewHistory()
+		h.Add("echo one").Add("echo two")
+
+		matches := h.Search("echo")
+		require.Len(t, matches, 2)
+		assert.Equal(t, "echo two", matches[0].GetInput())
+		assert.Equal(t, "echo one", matches[1].GetInput())
+	})
+
+	t.Run("NextPrevious", func(t *testing.T) {
+		h := NewHistory()
+		h.Add("echo one").Add("echo two").Add("ls")
+
+		s := NewSearch(h)
+		assert.Nil(t, s.GetMatch())
+
+		s.SetQuery("echo")
+		require.NotNil(t, s.GetMatch())
+		assert.Equal(t, "echo two", s.GetMatch().GetInput())
+
+		s.Next()
+		assert.Equal(t, "echo one", s.GetMatch().GetInput())
+		s.Next()
+		assert.Equal(t, "echo one", s.GetMatch().GetInput())
+
+		s.Previous()
+		assert.Equal(t, "echo two", s.GetMatch().GetInput())
+
+		s.SetQuery("nothing")
+		assert.Nil(t, s.GetMatch())
+		assert.Equal(t, "nothing", s.GetQuery())
+	})
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/bmichalkiewicz/gogut/ui"
 	tea "github.com/charmbracelet/bubbletea"
@@ -13,6 +14,14 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if input.GetSubcommand() != "" {
+		os.Exit(ui.RunSubcommand(input))
+	}
+
+	if input.IsHeadless() {
+		os.Exit(ui.RunHeadless(input))
+	}
+
 	if _, err := tea.NewProgram(ui.NewUI(input)).Run(); err != nil {
 		log.Fatal(err)
 	}
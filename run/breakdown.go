@@ -0,0 +1,126 @@
+package run
+
+import (
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Segment is a simple command of a command line along with the operator
+// chaining it to the previous one
+type Segment struct {
+	source    string
+	operator  string
+	name      string
+	flags     []string
+	args      []string
+	redirects []string
+}
+
+// GetSource returns the segment as written in the command line
+func (s Segment) GetSource() string {
+	return s.source
+}
+
+// GetOperator returns the operator joining the segment to the previous one,
+// like | or &&, empty for the first segment
+func (s Segment) GetOperator() string {
+	return s.operator
+}
+
+// GetName returns the command run by the segment, empty for compound
+// commands like loops or subshells
+func (s Segment) GetName() string {
+	return s.name
+}
+
+func (s Segment) GetFlags() []string {
+	return s.flags
+}
+
+// GetArgs returns the arguments which are not flags
+func (s Segment) GetArgs() []string {
+	return s.args
+}
+
+func (s Segment) GetRedirects() []string {
+	return s.redirects
+}
+
+// Breakdown splits a command line into its pipeline stages and chained
+// commands, and each of them into flags and arguments, a command the parser
+// does not understand for shells like fish is kept as a single segment
+func Breakdown(shell Shell, command string) ([]Segment, error) {
+	file, err := shell.parse(command)
+	if err != nil && !shell.isParsable() {
+		return []Segment{{source: strings.TrimSpace(command)}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []Segment
+	for i, stmt := range file.Stmts {
+		operator := ""
+		if i > 0 {
+			operator = ";"
+			if file.Stmts[i-1].Background {
+				operator = "&"
+			}
+		}
+		segments = append(segments, breakdownStmt(stmt, operator)...)
+	}
+
+	return segments, nil
+}
+
+func breakdownStmt(stmt *syntax.Stmt, operator string) []Segment {
+	if binary, ok := stmt.Cmd.(*syntax.BinaryCmd); ok {
+		return append(
+			breakdownStmt(binary.X, operator),
+			breakdownStmt(binary.Y, binary.Op.String())...,
+		)
+	}
+
+	segment := Segment{
+		source:   printNode(stmt),
+		operator: operator,
+	}
+
+	for _, redirect := range stmt.Redirs {
+		segment.redirects = append(segment.redirects, printRedirect(redirect))
+	}
+
+	call, ok := stmt.Cmd.(*syntax.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return []Segment{segment}
+	}
+
+	segment.name = getWordValue(call.Args[0])
+	for _, word := range call.Args[1:] {
+		arg := printNode(word)
+		if strings.HasPrefix(arg, "-") && arg != "-" && arg != "--" {
+			segment.flags = append(segment.flags, arg)
+		} else {
+			segment.args = append(segment.args, arg)
+		}
+	}
+
+	return []Segment{segment}
+}
+
+func printRedirect(redirect *syntax.Redirect) string {
+	var fd string
+	if redirect.N != nil {
+		fd = redirect.N.Value
+	}
+
+	return fd + redirect.Op.String() + printNode(redirect.Word)
+}
+
+func printNode(node syntax.Node) string {
+	var sb strings.Builder
+	syntax.NewPrinter(syntax.SingleLine(true)).Print(&sb, node)
+
+	return strings.TrimSpace(sb.String())
+}
@@ -0,0 +1,85 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBreakdown(t *testing.T) {
+	t.Run("Pipeline", testBreakdownPipeline)
+	t.Run("Chained", testBreakdownChained)
+	t.Run("Compound", testBreakdownCompound)
+	t.Run("Invalid", testBreakdownInvalid)
+	t.Run("UnparsedShell", testBreakdownUnparsedShell)
+}
+
+func testBreakdownPipeline(t *testing.T) {
+	segments, err := Breakdown(BashShell, `ps aux --sort=-%mem | grep -v "grep" | head -n 5 > top.txt`)
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+
+	assert.Equal(t, "", segments[0].GetOperator())
+	assert.Equal(t, "ps", segments[0].GetName())
+	assert.Equal(t, []string{"--sort=-%mem"}, segments[0].GetFlags())
+	assert.Equal(t, []string{"aux"}, segments[0].GetArgs())
+
+	assert.Equal(t, "|", segments[1].GetOperator())
+	assert.Equal(t, "grep", segments[1].GetName())
+	assert.Equal(t, []string{"-v"}, segments[1].GetFlags())
+	assert.Equal(t, []string{`"grep"`}, segments[1].GetArgs())
+
+	assert.Equal(t, "|", segments[2].GetOperator())
+	assert.Equal(t, "head", segments[2].GetName())
+	assert.Equal(t, []string{"-n"}, segments[2].GetFlags())
+	assert.Equal(t, []string{"5"}, segments[2].GetArgs())
+	assert.Equal(t, []string{">top.txt"}, segments[2].GetRedirects())
+	assert.Equal(t, "head -n 5 >top.txt", segments[2].GetSource())
+}
+
+func testBreakdownChained(t *testing.T) {
+	segments, err := Breakdown(BashShell, "mkdir -p build && cd build || exit 1; make &")
+	require.NoError(t, err)
+	require.Len(t, segments, 4)
+
+	var operators, names []string
+	for _, segment := range segments {
+		operators = append(operators, segment.GetOperator())
+		names = append(names, segment.GetName())
+	}
+
+	assert.Equal(t, []string{"", "&&", "||", ";"}, operators)
+	assert.Equal(t, []string{"mkdir", "cd", "exit", "make"}, names)
+}
+
+func testBreakdownCompound(t *testing.T) {
+	segments, err := Breakdown(BashShell, "for f in *.log; do gzip $f; done")
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	assert.Equal(t, "", segments[0].GetName())
+	assert.Contains(t, segments[0].GetSource(), "gzip $f")
+}
+
+func testBreakdownInvalid(t *testing.T) {
+	_, err := Breakdown(BashShell, "echo 'unterminated")
+	assert.Error(t, err)
+}
+
+func testBreakdownUnparsedShell(t *testing.T) {
+	segments, err := Breakdown(FishShell, " for f in *.txt; echo $f; end ")
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	assert.Equal(t, "for f in *.txt; echo $f; end", segments[0].GetSource())
+	assert.Equal(t, "", segments[0].GetName())
+
+	// what the parser understands is still broken down
+	segments, err = Breakdown(ZshShell, "ls -la | grep go")
+	require.NoError(t, err)
+	require.Len(t, segments, 2)
+	assert.Equal(t, "grep", segments[1].GetName())
+
+	_, err = Breakdown(ShShell, "[[ -f x ]] && echo 'unterminated")
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package run
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+)
+
+// Capture keeps the end of a command output, the part telling why it failed,
+// within a size limit
+type Capture struct {
+	mu        sync.Mutex
+	buffer    []byte
+	limit     int
+	truncated bool
+}
+
+func NewCapture(limit int) *Capture {
+	return &Capture{
+		limit: limit,
+	}
+}
+
+func (c *Capture) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.buffer = append(c.buffer, p...)
+	if len(c.buffer) > c.limit {
+		c.buffer = c.buffer[len(c.buffer)-c.limit:]
+		c.truncated = true
+	}
+
+	return len(p), nil
+}
+
+func (c *Capture) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return string(c.buffer)
+}
+
+// IsTruncated tells if the beginning of the output was dropped
+func (c *Capture) IsTruncated() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.truncated
+}
+
+// TeeOutput copies the stderr of the command to w, while still showing it to
+// the user, and its stdout too when it is not a terminal, stdin and a terminal
+// stdout are left alone so interactive and tty aware programs like vim, less
+// or ls --color keep the terminal
+func TeeOutput(c *exec.Cmd, w io.Writer) *exec.Cmd {
+	return teeOutput(c, w, isTerminal(os.Stdout))
+}
+
+func teeOutput(c *exec.Cmd, w io.Writer, terminal bool) *exec.Cmd {
+	c.Stderr = io.MultiWriter(os.Stderr, w)
+	if !terminal {
+		c.Stdout = io.MultiWriter(os.Stdout, w)
+	}
+
+	return c
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,65 @@
+package run
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapture(t *testing.T) {
+	t.Run("Write", testCaptureWrite)
+	t.Run("Truncated", testCaptureTruncated)
+	t.Run("TeeOutput", testCaptureTeeOutput)
+}
+
+func testCaptureWrite(t *testing.T) {
+	capture := NewCapture(100)
+
+	_, err := capture.Write([]byte("hello "))
+	require.NoError(t, err)
+	_, err = capture.Write([]byte("world"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "hello world", capture.String())
+	assert.False(t, capture.IsTruncated())
+}
+
+func testCaptureTruncated(t *testing.T) {
+	capture := NewCapture(5)
+
+	n, err := capture.Write([]byte("hello world"))
+	require.NoError(t, err)
+	assert.Equal(t, 11, n)
+
+	assert.Equal(t, "world", capture.String())
+	assert.True(t, capture.IsTruncated())
+}
+
+func testCaptureTeeOutput(t *testing.T) {
+	t.Run("Terminal", func(t *testing.T) {
+		capture := NewCapture(100)
+
+		c := teeOutput(exec.Command("sh", "-c", "echo out; echo err >&2; exit 3"), capture, true)
+		assert.Nil(t, c.Stdout)
+		assert.Nil(t, c.Stdin)
+
+		err := c.Run()
+
+		assert.Equal(t, 3, GetExitCode(err))
+		assert.Equal(t, "err\n", capture.String())
+	})
+
+	t.Run("Redirected", func(t *testing.T) {
+		capture := NewCapture(100)
+
+		c := teeOutput(exec.Command("sh", "-c", "echo out; sleep 0.1; echo err >&2; exit 3"), capture, false)
+		assert.Nil(t, c.Stdin)
+
+		err := c.Run()
+
+		assert.Equal(t, 3, GetExitCode(err))
+		assert.Equal(t, "out\nerr\n", capture.String())
+	})
+}
@@ -0,0 +1,521 @@
+package run
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Danger is a destructive pattern found in a command
+type Danger struct {
+	name        string
+	description string
+}
+
+func (d Danger) GetName() string {
+	return d.name
+}
+
+func (d Danger) GetDescription() string {
+	return d.description
+}
+
+// Rule flags a command line as dangerous, built in rules inspect the parsed
+// simple commands while pattern rules match regular expressions
+type Rule struct {
+	name        string
+	description string
+	match       func(line string, calls []call) bool
+}
+
+// NewPatternRule returns a rule matching pattern against the whole command
+// line and each of its simple commands
+func NewPatternRule(name, pattern, description string) (Rule, error) {
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return Rule{}, fmt.Errorf("invalid pattern for rule %s: %w", name, err)
+	}
+
+	return Rule{
+		name:        name,
+		description: description,
+		match: func(line string, calls []call) bool {
+			if expression.MatchString(line) {
+				return true
+			}
+			for _, c := range calls {
+				if expression.MatchString(strings.Join(c.args, " ")) {
+					return true
+				}
+			}
+			return false
+		},
+	}, nil
+}
+
+func (r Rule) GetName() string {
+	return r.name
+}
+
+func (r Rule) GetDescription() string {
+	return r.description
+}
+
+// Detector checks commands against the built in rules and the extra ones
+type Detector struct {
+	rules []Rule
+	shell Shell
+}
+
+func NewDetector(rules ...Rule) *Detector {
+	return &Detector{
+		rules: append(append([]Rule{}, builtinRules...), rules...),
+	}
+}
+
+// SetShell sets the shell running the commands, which picks the parser, bash by default
+func (d *Detector) SetShell(shell Shell) *Detector {
+	d.shell = shell
+
+	return d
+}
+
+// Check returns the dangers found in the command, a command which cannot be
+// parsed is reported as dangerous since it cannot be inspected, unless the
+// parser does not know the whole syntax of the shell, eg. fish, in which case
+// only the pattern rules apply, on the whole line
+func (d *Detector) Check(command string) []Danger {
+	calls, err := parseCalls(d.shell, command)
+	if err != nil && d.shell.isParsable() {
+		return []Danger{{
+			name:        "unparsable",
+			description: fmt.Sprintf("the command cannot be parsed (%s), review it carefully", err),
+		}}
+	}
+
+	var dangers []Danger
+	for _, rule := range d.rules {
+		if rule.match(command, calls) {
+			dangers = append(dangers, Danger{
+				name:        rule.name,
+				description: rule.description,
+			})
+		}
+	}
+
+	return dangers
+}
+
+// call is a simple command of a command line, wrappers like sudo stripped
+type call struct {
+	args []string
+	// piped lists the commands writing to this one in a pipeline
+	piped []string
+	// substituted lists the commands run in substitutions among the arguments
+	substituted []string
+}
+
+func (c call) getName() string {
+	if len(c.args) == 0 {
+		return ""
+	}
+
+	return filepath.Base(c.args[0])
+}
+
+func parseCalls(shell Shell, command string) ([]call, error) {
+	file, err := shell.parse(command)
+	if err != nil {
+		return nil, err
+	}
+
+	piped := map[*syntax.CallExpr][]string{}
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if binary, ok := node.(*syntax.BinaryCmd); ok && (binary.Op == syntax.Pipe || binary.Op == syntax.PipeAll) {
+			var names []string
+			for _, stage := range flattenPipeline(binary) {
+				expr, ok := stage.Cmd.(*syntax.CallExpr)
+				if !ok {
+					continue
+				}
+				if len(names) > 0 {
+					piped[expr] = append(piped[expr], names...)
+				}
+				if name := getCallName(expr); name != "" {
+					names = append(names, name)
+				}
+			}
+		}
+		return true
+	})
+
+	var calls []call
+	syntax.Walk(file, func(node syntax.Node) bool {
+		expr, ok := node.(*syntax.CallExpr)
+		if !ok || len(expr.Args) == 0 {
+			return true
+		}
+
+		args := make([]string, 0, len(expr.Args))
+		for _, word := range expr.Args {
+			args = append(args, getWordValue(word))
+		}
+
+		c := call{
+			args:        stripWrappers(args),
+			piped:       piped[expr],
+			substituted: getSubstitutedNames(expr),
+		}
+		calls = append(calls, c)
+
+		// the calls of scripts run by sh -c or eval are checked as well
+		if scriptShell, script, ok := getScript(shell, c.args); ok {
+			scriptCalls, scriptErr := parseCalls(scriptShell, script)
+			if scriptErr != nil && scriptShell.isParsable() {
+				err = fmt.Errorf("cannot parse the script run by %s: %w", c.getName(), scriptErr)
+				return false
+			}
+			calls = append(calls, scriptCalls...)
+		}
+
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return calls, nil
+}
+
+// getScript returns the script run by a call and the shell running it, for
+// shells given a script with -c, eg. bash -lc 'rm -rf /', and eval
+func getScript(shell Shell, args []string) (Shell, string, bool) {
+	name := call{args: args}.getName()
+	if name == "eval" {
+		return shell, strings.Join(args[1:], " "), len(args) > 1
+	}
+	if !contains(shells, name) {
+		return shell, "", false
+	}
+
+	command := false
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--command" && i+1 < len(args):
+			return GetShell(name), args[i+1], true
+		case strings.HasPrefix(arg, "--command="):
+			return GetShell(name), strings.TrimPrefix(arg, "--command="), true
+		case arg == "-o" || arg == "+o" || arg == "-O" || arg == "+O":
+			i++
+		case arg == "--":
+			if command && i+1 < len(args) {
+				return GetShell(name), args[i+1], true
+			}
+			return shell, "", false
+		case strings.HasPrefix(arg, "--"), strings.HasPrefix(arg, "+"):
+		case strings.HasPrefix(arg, "-"):
+			command = command || strings.ContainsRune(arg[1:], 'c')
+		default:
+			// the first operand is the script with -c, a script file otherwise
+			return GetShell(name), arg, command
+		}
+	}
+
+	return shell, "", false
+}
+
+func flattenPipeline(binary *syntax.BinaryCmd) []*syntax.Stmt {
+	var stages []*syntax.Stmt
+	for _, side := range []*syntax.Stmt{binary.X, binary.Y} {
+		if nested, ok := side.Cmd.(*syntax.BinaryCmd); ok && (nested.Op == syntax.Pipe || nested.Op == syntax.PipeAll) {
+			stages = append(stages, flattenPipeline(nested)...)
+		} else {
+			stages = append(stages, side)
+		}
+	}
+
+	return stages
+}
+
+func getCallName(expr *syntax.CallExpr) string {
+	args := make([]string, 0, len(expr.Args))
+	for _, word := range expr.Args {
+		args = append(args, getWordValue(word))
+	}
+
+	return call{args: stripWrappers(args)}.getName()
+}
+
+func getSubstitutedNames(expr *syntax.CallExpr) []string {
+	var names []string
+	for _, word := range expr.Args {
+		syntax.Walk(word, func(node syntax.Node) bool {
+			var stmts []*syntax.Stmt
+			switch substitution := node.(type) {
+			case *syntax.CmdSubst:
+				stmts = substitution.Stmts
+			case *syntax.ProcSubst:
+				stmts = substitution.Stmts
+			default:
+				return true
+			}
+			for _, stmt := range stmts {
+				syntax.Walk(stmt, func(node syntax.Node) bool {
+					if inner, ok := node.(*syntax.CallExpr); ok {
+						if name := getCallName(inner); name != "" {
+							names = append(names, name)
+						}
+					}
+					return true
+				})
+			}
+			return false
+		})
+	}
+
+	return names
+}
+
+// getWordValue returns the unquoted value of literal words, the source of the
+// words holding expansions
+func getWordValue(word *syntax.Word) string {
+	var sb strings.Builder
+	for _, part := range word.Parts {
+		switch p := part.(type) {
+		case *syntax.Lit:
+			sb.WriteString(p.Value)
+		case *syntax.SglQuoted:
+			sb.WriteString(p.Value)
+		case *syntax.DblQuoted:
+			for _, inner := range p.Parts {
+				if lit, ok := inner.(*syntax.Lit); ok {
+					sb.WriteString(lit.Value)
+				} else {
+					syntax.NewPrinter().Print(&sb, inner)
+				}
+			}
+		default:
+			syntax.NewPrinter().Print(&sb, part)
+		}
+	}
+
+	return sb.String()
+}
+
+// optionsWithValue lists, per wrapper, the options taking a value as next argument
+var optionsWithValue = map[string][]string{
+	"sudo":  {"-u", "-g", "-C", "-D", "-h", "-p", "-r", "-t", "-U"},
+	"doas":  {"-u", "-C"},
+	"env":   {"-u", "-C", "-S"},
+	"nice":  {"-n"},
+	"xargs": {"-I", "-n", "-P", "-d", "-L", "-s", "-E", "-a"},
+	"time":  {"-f", "-o"},
+	"nohup": {},
+	"exec":  {"-a"},
+}
+
+// stripWrappers drops the commands running another command, eg. sudo rm -rf / gives rm -rf /
+func stripWrappers(args []string) []string {
+	for len(args) > 0 {
+		name := filepath.Base(args[0])
+
+		valued, ok := optionsWithValue[name]
+		if !ok && name != "command" && name != "builtin" {
+			return args
+		}
+
+		args = args[1:]
+		for len(args) > 0 {
+			arg := args[0]
+			switch {
+			case arg == "--":
+				args = args[1:]
+			case strings.HasPrefix(arg, "-"):
+				args = args[1:]
+				for _, option := range valued {
+					if arg == option && len(args) > 0 {
+						args = args[1:]
+					}
+				}
+				continue
+			case name == "env" && strings.Contains(arg, "="):
+				args = args[1:]
+				continue
+			}
+			break
+		}
+	}
+
+	return args
+}
+
+// hasFlag tells if args hold the long flag or the short one, alone or in a cluster like -rf
+func hasFlag(args []string, short rune, long string) bool {
+	for _, arg := range args {
+		if arg == long || strings.HasPrefix(arg, long+"=") {
+			return true
+		}
+		if short != 0 && strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--") && strings.ContainsRune(arg[1:], short) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasArg(args []string, values ...string) bool {
+	for _, arg := range args {
+		for _, value := range values {
+			if arg == value {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// systemPaths are the directories a recursive removal should never target
+var systemPaths = []string{
+	"", "~", "$HOME", "${HOME}", "/bin", "/boot", "/dev", "/etc", "/home", "/lib", "/lib64",
+	"/opt", "/proc", "/root", "/sbin", "/sys", "/usr", "/var", "*", ".", "..",
+}
+
+func isSystemPath(path string) bool {
+	path = strings.TrimSuffix(path, "*")
+	path = strings.TrimRight(path, "/")
+	for _, systemPath := range systemPaths {
+		if path == systemPath {
+			return true
+		}
+	}
+
+	return false
+}
+
+var shells = []string{"sh", "bash", "zsh", "fish", "dash", "ksh"}
+
+var downloaders = []string{"curl", "wget"}
+
+func contains(values []string, names ...string) bool {
+	for _, value := range values {
+		for _, name := range names {
+			if value == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchCalls builds a rule matching every simple command with fn
+func matchCalls(fn func(c call) bool) func(line string, calls []call) bool {
+	return func(line string, calls []call) bool {
+		for _, c := range calls {
+			if fn(c) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+var builtinRules = []Rule{
+	{
+		name:        "recursive-removal",
+		description: "recursively removes a system or home directory",
+		match: matchCalls(func(c call) bool {
+			if c.getName() != "rm" || !hasFlag(c.args[1:], 'r', "--recursive") && !hasFlag(c.args[1:], 'R', "--recursive") {
+				return false
+			}
+			if hasArg(c.args[1:], "--no-preserve-root") {
+				return true
+			}
+			for _, arg := range c.args[1:] {
+				if !strings.HasPrefix(arg, "-") && isSystemPath(arg) {
+					return true
+				}
+			}
+			return false
+		}),
+	},
+	{
+		name:        "device-write",
+		description: "writes directly to a device, erasing its content",
+		match: matchCalls(func(c call) bool {
+			if c.getName() != "dd" {
+				return false
+			}
+			for _, arg := range c.args[1:] {
+				if strings.HasPrefix(arg, "of=/dev/") && arg != "of=/dev/null" {
+					return true
+				}
+			}
+			return false
+		}),
+	},
+	{
+		name:        "filesystem-format",
+		description: "formats a filesystem, erasing its content",
+		match: matchCalls(func(c call) bool {
+			name := c.getName()
+			return name == "mkfs" || strings.HasPrefix(name, "mkfs.") || name == "mke2fs" || name == "wipefs"
+		}),
+	},
+	{
+		name:        "world-writable",
+		description: "recursively makes files writable by everyone",
+		match: matchCalls(func(c call) bool {
+			if c.getName() != "chmod" || !hasFlag(c.args[1:], 'R', "--recursive") {
+				return false
+			}
+			return hasArg(c.args[1:], "777", "0777", "a+rwx", "ugo+rwx", "a=rwx", "o+w", "a+w")
+		}),
+	},
+	{
+		name:        "remote-script",
+		description: "runs a script downloaded from the network without reviewing it",
+		match: matchCalls(func(c call) bool {
+			if !contains(shells, c.getName()) {
+				return false
+			}
+			return contains(c.piped, downloaders...) || contains(c.substituted, downloaders...)
+		}),
+	},
+	{
+		name:        "force-push",
+		description: "force pushes, overwriting the remote history",
+		match: matchCalls(func(c call) bool {
+			if c.getName() != "git" || !hasArg(c.args[1:], "push") {
+				return false
+			}
+			if hasFlag(c.args[1:], 'f', "--force") {
+				return true
+			}
+			for _, arg := range c.args[1:] {
+				if strings.HasPrefix(arg, "+") {
+					return true
+				}
+			}
+			return false
+		}),
+	},
+	{
+		name:        "kubernetes-mass-deletion",
+		description: "deletes kubernetes resources across namespaces",
+		match: matchCalls(func(c call) bool {
+			if c.getName() != "kubectl" || !hasArg(c.args[1:], "delete") {
+				return false
+			}
+			if hasArg(c.args[1:], "-A") || hasFlag(c.args[1:], 0, "--all-namespaces") {
+				return true
+			}
+			return hasArg(c.args[1:], "ns", "namespace", "namespaces") && hasFlag(c.args[1:], 0, "--all")
+		}),
+	},
+}
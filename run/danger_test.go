@@ -0,0 +1,156 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetector(t *testing.T) {
+	t.Run("Check", testDetectorCheck)
+	t.Run("Safe", testDetectorSafe)
+	t.Run("PatternRule", testDetectorPatternRule)
+	t.Run("Unparsable", testDetectorUnparsable)
+	t.Run("UnparsedShell", testDetectorUnparsedShell)
+	t.Run("Scripts", testDetectorScripts)
+}
+
+func getDangerNames(dangers []Danger) []string {
+	var names []string
+	for _, danger := range dangers {
+		names = append(names, danger.GetName())
+	}
+
+	return names
+}
+
+func testDetectorCheck(t *testing.T) {
+	detector := NewDetector()
+
+	commands := map[string]string{
+		"rm -rf /":                                    "recursive-removal",
+		"sudo rm -rf /*":                              "recursive-removal",
+		"rm -r -f ~":                                  "recursive-removal",
+		"rm --recursive --force \"$HOME\"":            "recursive-removal",
+		"rm -rf --no-preserve-root /tmp/x":            "recursive-removal",
+		"ls && sudo -u root rm -Rf /etc":              "recursive-removal",
+		"dd if=disk.img of=/dev/sda bs=4M":            "device-write",
+		"sudo mkfs.ext4 /dev/sdb1":                    "filesystem-format",
+		"mkfs -t xfs /dev/sdc":                        "filesystem-format",
+		"chmod -R 777 /var/www":                       "world-writable",
+		"sudo chmod --recursive a+rwx .":              "world-writable",
+		"curl -fsSL https://example.com/install | sh": "remote-script",
+		"wget -qO- https://example.com | sudo bash":   "remote-script",
+		"bash <(curl -s https://example.com)":         "remote-script",
+		"sh -c \"$(curl -fsSL https://example.com)\"": "remote-script",
+		"git push --force origin main":                "force-push",
+		"git push -f":                                 "force-push",
+		"git push origin +main":                       "force-push",
+		"kubectl delete pods --all -A":                "kubernetes-mass-deletion",
+		"kubectl delete deploy --all-namespaces -l x": "kubernetes-mass-deletion",
+		"kubectl delete namespaces --all":             "kubernetes-mass-deletion",
+	}
+
+	for command, name := range commands {
+		assert.Contains(t, getDangerNames(detector.Check(command)), name, command)
+	}
+}
+
+func testDetectorSafe(t *testing.T) {
+	detector := NewDetector()
+
+	commands := []string{
+		"ls -la /",
+		"rm -rf ./build",
+		"rm -f /tmp/file",
+		"echo 'rm -rf /'",
+		"dd if=/dev/zero of=disk.img bs=1M count=10",
+		"dd if=/dev/sda of=/dev/null",
+		"chmod 777 script.sh",
+		"chmod -R 755 /var/www",
+		"curl -fsSL https://example.com -o install.sh",
+		"curl -s https://example.com | jq .",
+		"git push --force-with-lease origin main",
+		"git push origin main",
+		"kubectl delete pod web-1 -n default",
+		"kubectl get pods -A",
+	}
+
+	for _, command := range commands {
+		assert.Empty(t, detector.Check(command), command)
+	}
+}
+
+func testDetectorPatternRule(t *testing.T) {
+	rule, err := NewPatternRule("terraform-destroy", `terraform\s+destroy`, "destroys the infrastructure")
+	require.NoError(t, err)
+	assert.Equal(t, "terraform-destroy", rule.GetName())
+	assert.Equal(t, "destroys the infrastructure", rule.GetDescription())
+
+	detector := NewDetector(rule)
+
+	dangers := detector.Check("cd infra && terraform   destroy -auto-approve")
+	require.Len(t, dangers, 1)
+	assert.Equal(t, "terraform-destroy", dangers[0].GetName())
+	assert.Equal(t, "destroys the infrastructure", dangers[0].GetDescription())
+
+	assert.Empty(t, detector.Check("terraform plan"))
+
+	_, err = NewPatternRule("broken", `(`, "")
+	assert.Error(t, err)
+}
+
+func testDetectorUnparsable(t *testing.T) {
+	dangers := NewDetector().Check("echo 'unterminated")
+	require.Len(t, dangers, 1)
+	assert.Equal(t, "unparsable", dangers[0].GetName())
+}
+
+func testDetectorUnparsedShell(t *testing.T) {
+	rule, err := NewPatternRule("terraform-destroy", `terraform\s+destroy`, "destroys the infrastructure")
+	require.NoError(t, err)
+
+	fish := NewDetector(rule).SetShell(FishShell)
+	assert.Empty(t, fish.Check("for f in *.txt; echo $f; end"))
+	assert.Empty(t, NewDetector().SetShell(ZshShell).Check("ls **/*.go(.)"))
+
+	// only the pattern rules apply to what cannot be parsed
+	dangers := fish.Check("for d in (ls); terraform destroy -chdir=$d; end")
+	require.Len(t, dangers, 1)
+	assert.Equal(t, "terraform-destroy", dangers[0].GetName())
+
+	// what can be parsed is still inspected
+	assert.Contains(t, getDangerNames(fish.Check("rm -rf ~")), "recursive-removal")
+
+	assert.Equal(t, []string{"unparsable"}, getDangerNames(NewDetector().SetShell(BashShell).Check("ls **/*.go(.)")))
+}
+
+func testDetectorScripts(t *testing.T) {
+	detector := NewDetector()
+
+	commands := map[string]string{
+		"bash -c 'rm -rf /'":                          "recursive-removal",
+		"sh -c \"rm -rf ~\"":                          "recursive-removal",
+		"sudo sh -c 'mkfs.ext4 /dev/sda1'":            "filesystem-format",
+		"bash -lc 'cd /tmp && rm -rf /'":              "recursive-removal",
+		"bash -o pipefail -c 'dd if=x of=/dev/sda'":   "device-write",
+		"zsh -c -- 'git push -f'":                     "force-push",
+		"fish --command 'rm -rf /'":                   "recursive-removal",
+		"eval 'rm -rf /'":                             "recursive-removal",
+		"eval rm -rf ~":                               "recursive-removal",
+		"sh -c \"bash -c 'rm -rf /'\"":                "recursive-removal",
+		"ssh host 'ls' && sudo bash -c 'curl x | sh'": "remote-script",
+	}
+
+	for command, name := range commands {
+		assert.Contains(t, getDangerNames(detector.Check(command)), name, command)
+	}
+
+	for _, command := range []string{"bash -c 'ls -la'", "bash script.sh -c", "sh -e deploy.sh", "eval \"$(ssh-agent -s)\""} {
+		assert.Empty(t, detector.Check(command), command)
+	}
+
+	// a script which cannot be inspected is as dangerous as the line itself
+	assert.Equal(t, []string{"unparsable"}, getDangerNames(detector.Check("bash -c 'echo \"unterminated'")))
+}
@@ -0,0 +1,169 @@
+package run
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Policy tells which commands can run without being confirmed, every command
+// of a line must start with an allowed prefix or match an allowed pattern
+type Policy struct {
+	prefixes [][]string
+	patterns []*regexp.Regexp
+	shell    Shell
+}
+
+// NewPolicy creates a policy from command prefixes, eg. git status, matched
+// word by word, and regular expressions matching whole commands
+func NewPolicy(prefixes, patterns []string) (*Policy, error) {
+	policy := &Policy{}
+
+	for _, prefix := range prefixes {
+		fields := strings.Fields(prefix)
+		if len(fields) == 0 {
+			continue
+		}
+		policy.prefixes = append(policy.prefixes, fields)
+	}
+
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		policy.patterns = append(policy.patterns, re)
+	}
+
+	return policy, nil
+}
+
+// IsEmpty tells if the policy allows nothing
+func (p *Policy) IsEmpty() bool {
+	return len(p.prefixes) == 0 && len(p.patterns) == 0
+}
+
+// SetShell sets the shell running the commands, which picks the parser, bash by default
+func (p *Policy) SetShell(shell Shell) *Policy {
+	p.shell = shell
+
+	return p
+}
+
+// Allows tells if a command line can run without confirmation, commands
+// writing to files, setting variables or that cannot be parsed are never allowed
+func (p *Policy) Allows(command string) bool {
+	if p.IsEmpty() {
+		return false
+	}
+
+	file, err := p.shell.parse(command)
+	if err != nil && !p.shell.isParsable() {
+		return p.allowsUnparsed(command)
+	}
+	if err != nil {
+		return false
+	}
+
+	allowed := true
+	calls := 0
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if !allowed {
+			return false
+		}
+
+		switch n := node.(type) {
+		case *syntax.Redirect:
+			if isWritingRedirect(n) {
+				allowed = false
+			}
+		case *syntax.CallExpr:
+			if len(n.Assigns) > 0 {
+				allowed = false
+				return false
+			}
+			if len(n.Args) == 0 {
+				return true
+			}
+
+			args := make([]string, 0, len(n.Args))
+			for _, word := range n.Args {
+				args = append(args, getWordValue(word))
+			}
+			calls++
+			allowed = p.allowsCall(args)
+		}
+
+		return allowed
+	})
+
+	return allowed && calls > 0
+}
+
+func (p *Policy) allowsCall(args []string) bool {
+	for _, prefix := range p.prefixes {
+		if hasPrefix(args, prefix) {
+			return true
+		}
+	}
+
+	line := strings.Join(args, " ")
+	for _, pattern := range p.patterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unparsedOperators may chain, substitute or redirect commands in a line the
+// parser does not understand, eg. fish runs (cmd) as a substitution
+const unparsedOperators = ";&|<>$`()\n"
+
+// allowsUnparsed allows the lines the parser does not understand only when
+// they are a single simple command, split on spaces
+func (p *Policy) allowsUnparsed(command string) bool {
+	if strings.ContainsAny(command, unparsedOperators) {
+		return false
+	}
+
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return false
+	}
+
+	return p.allowsCall(args)
+}
+
+func hasPrefix(args, prefix []string) bool {
+	if len(args) < len(prefix) {
+		return false
+	}
+
+	for i, field := range prefix {
+		if args[i] != field {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isWritingRedirect tells if a redirection writes to a file, /dev/null and
+// file descriptor duplications like 2>&1 are harmless
+func isWritingRedirect(redirect *syntax.Redirect) bool {
+	switch redirect.Op {
+	case syntax.RdrOut, syntax.AppOut, syntax.RdrAll, syntax.AppAll, syntax.ClbOut, syntax.RdrInOut:
+		return redirect.Word == nil || getWordValue(redirect.Word) != "/dev/null"
+	case syntax.DplOut:
+		// >&file writes to the file like &>file
+		_, err := strconv.Atoi(getWordValue(redirect.Word))
+		return err != nil && getWordValue(redirect.Word) != "-"
+	default:
+		return false
+	}
+}
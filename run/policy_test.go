@@ -0,0 +1,115 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Run("Prefixes", testPolicyPrefixes)
+	t.Run("Patterns", testPolicyPatterns)
+	t.Run("Denied", testPolicyDenied)
+	t.Run("Empty", testPolicyEmpty)
+	t.Run("InvalidPattern", testPolicyInvalidPattern)
+	t.Run("UnparsedShell", testPolicyUnparsedShell)
+}
+
+func testPolicyPrefixes(t *testing.T) {
+	policy, err := NewPolicy([]string{"git status", "kubectl get", "ls", "grep"}, nil)
+	require.NoError(t, err)
+
+	commands := []string{
+		"ls",
+		"ls -la /tmp",
+		"git status --short",
+		"kubectl get pods -A",
+		"ls | grep foo",
+		"ls && git status",
+		"git   status",
+		"ls 2>/dev/null",
+		"ls 2>&1 | grep foo",
+		"ls $(git status --porcelain)",
+	}
+
+	for _, command := range commands {
+		assert.True(t, policy.Allows(command), command)
+	}
+}
+
+func testPolicyPatterns(t *testing.T) {
+	policy, err := NewPolicy(nil, []string{`docker (ps|images)( -a)?`, `cat /var/log/\S+`})
+	require.NoError(t, err)
+
+	assert.True(t, policy.Allows("docker ps"))
+	assert.True(t, policy.Allows("docker images -a"))
+	assert.True(t, policy.Allows("cat /var/log/syslog"))
+
+	// patterns match whole commands
+	assert.False(t, policy.Allows("docker ps -q | xargs docker rm"))
+	assert.False(t, policy.Allows("docker rm web"))
+	assert.False(t, policy.Allows("cat /var/log/syslog /etc/shadow"))
+}
+
+func testPolicyDenied(t *testing.T) {
+	policy, err := NewPolicy([]string{"git status", "kubectl get", "ls", "echo"}, nil)
+	require.NoError(t, err)
+
+	commands := []string{
+		"git",
+		"git push",
+		"lsblk",
+		"kubectl delete pods --all",
+		"sudo ls",
+		"ls; rm -rf ~",
+		"ls && rm file",
+		"ls | xargs rm",
+		"ls $(rm -rf ~)",
+		"echo `rm -rf ~`",
+		"ls > files.txt",
+		"echo data >> ~/.bashrc",
+		"ls &> out.log",
+		"ls >& out.log",
+		"PAGER=rm git status",
+		"FOO=bar",
+		"ls 'unterminated",
+		"",
+	}
+
+	for _, command := range commands {
+		assert.False(t, policy.Allows(command), command)
+	}
+}
+
+func testPolicyEmpty(t *testing.T) {
+	policy, err := NewPolicy([]string{"", "  "}, nil)
+	require.NoError(t, err)
+
+	assert.True(t, policy.IsEmpty())
+	assert.False(t, policy.Allows("ls"))
+}
+
+func testPolicyInvalidPattern(t *testing.T) {
+	_, err := NewPolicy(nil, []string{`(`})
+	assert.Error(t, err)
+}
+
+func testPolicyUnparsedShell(t *testing.T) {
+	policy, err := NewPolicy([]string{"ls", "echo"}, []string{`git log .*`})
+	require.NoError(t, err)
+	policy.SetShell(ZshShell)
+
+	// glob qualifiers are not understood by the parser
+	assert.False(t, policy.Allows("ls **/*.go(.)"))
+	assert.True(t, policy.Allows("ls **/*.go"))
+	assert.False(t, policy.Allows("ls -la | grep go"))
+
+	policy.SetShell(FishShell)
+	assert.True(t, policy.Allows("echo {a,b}"))
+	assert.True(t, policy.Allows("git log --oneline -5"))
+	// fish substitutions and chains are never allowed without the parser
+	assert.False(t, policy.Allows("echo (rm -rf ~)"))
+	assert.False(t, policy.Allows("for f in *.txt; echo $f; end"))
+	assert.False(t, policy.Allows("ls; and rm -rf ~"))
+}
@@ -1,9 +1,11 @@
 package run
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
-	"strings"
 )
 
 func RunCommand(cmd string, arg ...string) (string, error) {
@@ -15,18 +17,92 @@ func RunCommand(cmd string, arg ...string) (string, error) {
 	return string(out), nil
 }
 
-func PrepareInteractiveCommand(input string) *exec.Cmd {
-	return exec.Command(
-		"bash",
-		"-c",
-		fmt.Sprintf("echo \"\n\";%s; echo \"\n\";", strings.TrimRight(input, ";")),
-	)
+// GetExitCode returns the exit code of a finished command, -1 when it could not run
+func GetExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	return -1
+}
+
+// separator surrounds the output of the commands, as the blank line echoed by the shell used to
+const separator = "\n\n"
+
+// InteractiveCommand runs a command in the terminal between separators,
+// printed from go so that nothing in the command can swallow them
+type InteractiveCommand struct {
+	*exec.Cmd
+	out    io.Writer
+	before bool
+	after  bool
+}
+
+// PrepareInteractiveCommand runs the input with the shell, passed as is as
+// its own argument so comments, heredocs or unbalanced quotes stay contained
+func PrepareInteractiveCommand(shell Shell, input string) *InteractiveCommand {
+	return &InteractiveCommand{
+		Cmd:    shell.Command(input),
+		before: true,
+		after:  true,
+	}
+}
+
+// PrepareEditSettingsCommand opens the file with the editor, which may come with arguments like code -w
+func PrepareEditSettingsCommand(shell Shell, editor, file string) *InteractiveCommand {
+	return &InteractiveCommand{
+		Cmd:   shell.Command(fmt.Sprintf("%s %s", editor, shell.Quote(file))),
+		after: true,
+	}
 }
 
-func PrepareEditSettingsCommand(input string) *exec.Cmd {
-	return exec.Command(
-		"bash",
-		"-c",
-		fmt.Sprintf("%s; echo \"\n\";", strings.TrimRight(input, ";")),
-	)
+func (c *InteractiveCommand) Run() error {
+	out := c.out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	if c.before {
+		fmt.Fprint(out, separator)
+	}
+
+	err := c.Cmd.Run()
+
+	if c.after {
+		fmt.Fprint(out, separator)
+	}
+
+	return err
+}
+
+// SetStdin sets the stdin of the command, unless already set
+func (c *InteractiveCommand) SetStdin(r io.Reader) {
+	if c.Stdin == nil {
+		c.Stdin = r
+	}
+}
+
+// SetStdout sets where the separators are printed, and the stdout of the
+// command unless already set, when its output is captured
+func (c *InteractiveCommand) SetStdout(w io.Writer) {
+	if w == nil {
+		return
+	}
+
+	c.out = w
+	if c.Stdout == nil {
+		c.Stdout = w
+	}
+}
+
+// SetStderr sets the stderr of the command, unless already set
+func (c *InteractiveCommand) SetStderr(w io.Writer) {
+	if c.Stderr == nil {
+		c.Stderr = w
+	}
 }
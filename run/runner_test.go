@@ -0,0 +1,100 @@
+package run
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner(t *testing.T) {
+	t.Run("Separators", testRunnerSeparators)
+	t.Run("Comment", testRunnerComment)
+	t.Run("Heredoc", testRunnerHeredoc)
+	t.Run("Background", testRunnerBackground)
+	t.Run("MultiStatement", testRunnerMultiStatement)
+	t.Run("UnbalancedQuotes", testRunnerUnbalancedQuotes)
+	t.Run("EditSettings", testRunnerEditSettings)
+	t.Run("Quote", testRunnerQuote)
+}
+
+func runInteractive(t *testing.T, input string) (string, error) {
+	t.Helper()
+
+	var out bytes.Buffer
+	c := PrepareInteractiveCommand(ShShell, input)
+	c.SetStdout(&out)
+	c.SetStderr(&out)
+
+	err := c.Run()
+
+	return out.String(), err
+}
+
+func testRunnerSeparators(t *testing.T) {
+	out, err := runInteractive(t, "echo hello")
+	require.NoError(t, err)
+
+	assert.Equal(t, separator+"hello\n"+separator, out)
+	assert.Equal(t, []string{"sh", "-c", "echo hello"}, PrepareInteractiveCommand(ShShell, "echo hello").Args)
+}
+
+func testRunnerComment(t *testing.T) {
+	out, err := runInteractive(t, "echo hello # say hello")
+	require.NoError(t, err)
+
+	assert.Equal(t, separator+"hello\n"+separator, out)
+}
+
+func testRunnerHeredoc(t *testing.T) {
+	out, err := runInteractive(t, "cat <<EOF\nfirst line\nsecond line\nEOF")
+	require.NoError(t, err)
+
+	assert.Equal(t, separator+"first line\nsecond line\n"+separator, out)
+}
+
+func testRunnerBackground(t *testing.T) {
+	out, err := runInteractive(t, "echo background > /dev/null &")
+	require.NoError(t, err)
+
+	assert.Equal(t, separator+separator, out)
+}
+
+func testRunnerMultiStatement(t *testing.T) {
+	out, err := runInteractive(t, "echo one; echo two && echo three;")
+	require.NoError(t, err)
+	assert.Equal(t, separator+"one\ntwo\nthree\n"+separator, out)
+
+	out, err = runInteractive(t, "echo one; exit 4")
+	assert.Equal(t, 4, GetExitCode(err))
+	assert.True(t, strings.HasSuffix(out, "one\n"+separator))
+}
+
+func testRunnerUnbalancedQuotes(t *testing.T) {
+	out, err := runInteractive(t, "echo 'unterminated")
+	assert.Error(t, err)
+
+	assert.True(t, strings.HasPrefix(out, separator))
+	assert.True(t, strings.HasSuffix(out, separator))
+}
+
+func testRunnerEditSettings(t *testing.T) {
+	c := PrepareEditSettingsCommand(ShShell, "echo", "/tmp/my config.yaml")
+	assert.Equal(t, []string{"sh", "-c", "echo '/tmp/my config.yaml'"}, c.Args)
+
+	var out bytes.Buffer
+	c.SetStdout(&out)
+	require.NoError(t, c.Run())
+	assert.Equal(t, "/tmp/my config.yaml\n"+separator, out.String())
+}
+
+func testRunnerQuote(t *testing.T) {
+	assert.Equal(t, `'it'\''s here'`, ShShell.Quote("it's here"))
+	assert.Equal(t, `'it\'s \\here'`, FishShell.Quote(`it's \here`))
+
+	out, err := ShShell.Command("printf %s " + ShShell.Quote(`a 'b' $c`)).Output()
+	require.NoError(t, err)
+	assert.Equal(t, `a 'b' $c`, string(out))
+}
@@ -0,0 +1,69 @@
+package run
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// posixShells run under sh, they share its syntax
+var posixShells = []string{"sh", "dash", "ash", "ksh", "mksh", "busybox"}
+
+// GetShell returns the shell running the commands for the user shell name or
+// path, shells gogut cannot drive fall back on bash, or sh when bash is missing
+func GetShell(name string) Shell {
+	name = strings.TrimSpace(filepath.Base(name))
+
+	switch {
+	case name == "bash":
+		return BashShell
+	case name == "zsh":
+		return ZshShell
+	case name == "fish":
+		return FishShell
+	case contains(posixShells, name):
+		return ShShell
+	}
+
+	if _, err := exec.LookPath(BashShell.String()); err != nil {
+		return ShShell
+	}
+
+	return BashShell
+}
+
+// Command returns the command running the script with the shell, every
+// supported shell takes it with -c as a non-interactive shell
+func (s Shell) Command(script string) *exec.Cmd {
+	return exec.Command(s.String(), "-c", script)
+}
+
+// Quote returns the argument quoted for the shell, as a single word
+func (s Shell) Quote(arg string) string {
+	if s == FishShell {
+		// fish single quotes only escape backslashes and single quotes
+		return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(arg) + "'"
+	}
+
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// parse parses a command line with the parser variant of the shell, zsh and
+// fish have none so their commands are parsed as bash, which covers most of
+// their one-liners
+func (s Shell) parse(command string) (*syntax.File, error) {
+	variant := syntax.LangBash
+	if s == ShShell {
+		variant = syntax.LangPOSIX
+	}
+
+	return syntax.NewParser(syntax.Variant(variant)).Parse(strings.NewReader(command), "")
+}
+
+// isParsable tells if the parser understands the whole syntax of the shell,
+// otherwise a parse failure does not mean the command is invalid
+func (s Shell) isParsable() bool {
+	return s == BashShell || s == ShShell
+}
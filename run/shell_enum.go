@@ -0,0 +1,23 @@
+package run
+
+type Shell int
+
+const (
+	BashShell Shell = iota
+	ZshShell
+	FishShell
+	ShShell
+)
+
+func (s Shell) String() string {
+	switch s {
+	case ZshShell:
+		return "zsh"
+	case FishShell:
+		return "fish"
+	case ShShell:
+		return "sh"
+	default:
+		return "bash"
+	}
+}
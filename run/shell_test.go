@@ -0,0 +1,40 @@
+package run
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShell(t *testing.T) {
+	t.Run("GetShell", testGetShell)
+	t.Run("Command", testShellCommand)
+}
+
+func testGetShell(t *testing.T) {
+	assert.Equal(t, BashShell, GetShell("bash"))
+	assert.Equal(t, ZshShell, GetShell("/usr/bin/zsh"))
+	assert.Equal(t, FishShell, GetShell("/opt/homebrew/bin/fish"))
+	assert.Equal(t, ShShell, GetShell("dash"))
+	assert.Equal(t, ShShell, GetShell("ksh"))
+
+	fallback := BashShell
+	if _, err := exec.LookPath("bash"); err != nil {
+		fallback = ShShell
+	}
+	assert.Equal(t, fallback, GetShell("nu"))
+	assert.Equal(t, fallback, GetShell(""))
+}
+
+func testShellCommand(t *testing.T) {
+	assert.Equal(t, []string{"zsh", "-c", "ls -la"}, ZshShell.Command("ls -la").Args)
+	assert.Equal(t, []string{"fish", "-c", "ls -la"}, FishShell.Command("ls -la").Args)
+
+	c := PrepareInteractiveCommand(ShShell, "echo hello;")
+	assert.Equal(t, "sh", c.Args[0])
+
+	out, err := c.Output()
+	assert.NoError(t, err)
+	assert.Contains(t, string(out), "hello")
+}
@@ -0,0 +1,126 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	summaryReplCommand  = "/summary"
+	commandsReplCommand = "/commands"
+	rerunReplCommand    = "/rerun"
+	dryRunReplCommand   = "/dry-run"
+)
+
+const maxListedCommands = 20
+
+var replCommands = []string{summaryReplCommand, commandsReplCommand, rerunReplCommand, dryRunReplCommand}
+
+// isReplCommand tells if the input is a REPL command rather than a prompt, eg. a path like /etc/hosts
+func isReplCommand(input string) bool {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return false
+	}
+
+	for _, command := range replCommands {
+		if fields[0] == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+// runReplCommand handles the REPL commands locally, without calling the model
+func (u *UI) runReplCommand(input string) tea.Cmd {
+	fields := strings.Fields(input)
+
+	switch fields[0] {
+	case summaryReplCommand:
+		return u.showSummary()
+	case commandsReplCommand:
+		return u.listCommands(strings.Join(fields[1:], " "))
+	case rerunReplCommand:
+		if len(fields) < 2 {
+			return tea.Println(u.components.renderer.RenderError(fmt.Sprintf("\n[usage: %s <number>]\n", rerunReplCommand)))
+		}
+		return u.rerunCommand(fields[1])
+	case dryRunReplCommand:
+		return u.toggleDryRun()
+	default:
+		return nil
+	}
+}
+
+func (u *UI) showSummary() tea.Cmd {
+	summary := u.engine.GetSummary()
+	if summary == "" {
+		return tea.Println(u.components.renderer.RenderHelp("\n  [no summary yet, older chat turns are summarized once the chat grows large]\n"))
+	}
+
+	return tea.Println(u.components.renderer.RenderContent(fmt.Sprintf("**Summary**\n\n%s", summary)))
+}
+
+// listCommands prints the executed commands matching the query, numbered for /rerun
+func (u *UI) listCommands(query string) tea.Cmd {
+	if u.commands == nil {
+		return nil
+	}
+
+	commands, err := u.commands.Search(query)
+	if err != nil {
+		return tea.Println(u.components.renderer.RenderError(fmt.Sprintf("\n[commands error] %s\n", err)))
+	}
+
+	if len(commands) > maxListedCommands {
+		commands = commands[:maxListedCommands]
+	}
+	u.state.commands = commands
+
+	if len(commands) == 0 {
+		return tea.Println(u.components.renderer.RenderHelp("\n  [no executed command found]\n"))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("**Commands**\n\n")
+	for i, command := range commands {
+		sb.WriteString(fmt.Sprintf(
+			"%d. `%s` *%s*  \n   %s, exit %d in %s, from `%s`\n",
+			i+1,
+			command.GetCommand(),
+			command.GetPrompt(),
+			command.GetTimestamp().Local().Format("2006-01-02 15:04"),
+			command.GetExitCode(),
+			command.GetDuration().Round(100*time.Millisecond),
+			command.GetCwd(),
+		))
+	}
+	sb.WriteString(fmt.Sprintf("\nUse `%s <number>` to run one of them again.\n", rerunReplCommand))
+
+	return tea.Println(u.components.renderer.RenderContent(sb.String()))
+}
+
+// rerunCommand asks to confirm a command of the last listing, or of the most
+// recent commands without listing, the model is not called
+func (u *UI) rerunCommand(number string) tea.Cmd {
+	commands := u.state.commands
+	if len(commands) == 0 && u.commands != nil {
+		commands, _ = u.commands.GetAll()
+	}
+
+	index, err := strconv.Atoi(number)
+	if err != nil || index < 1 || index > len(commands) {
+		return tea.Println(u.components.renderer.RenderError(fmt.Sprintf("\n[no command %s, list them with %s]\n", number, commandsReplCommand)))
+	}
+
+	command := commands[index-1]
+	u.state.prompt = command.GetPrompt()
+	u.state.attempts = 0
+
+	return tea.Println(u.confirmCommand(command.GetCommand(), ""))
+}
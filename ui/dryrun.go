@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bmichalkiewicz/gogut/run"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (u *UI) toggleDryRun() tea.Cmd {
+	u.state.dryRun = !u.state.dryRun
+
+	if u.state.dryRun {
+		return tea.Println(u.components.renderer.RenderHelp("\n  [dry run on, commands are never executed]\n"))
+	}
+
+	return tea.Println(u.components.renderer.RenderHelp("\n  [dry run off]\n"))
+}
+
+// renderDryRun shows the command with a breakdown of its stages, instead of asking to execute it
+func (u *UI) renderDryRun(command, explanation string) string {
+	output := u.components.renderer.RenderContent(fmt.Sprintf("`%s`", command))
+	if explanation != "" {
+		output += fmt.Sprintf("  %s\n", u.components.renderer.RenderHelp(explanation))
+	}
+
+	segments, err := run.Breakdown(u.getShell(), command)
+	if err != nil {
+		return output + u.components.renderer.RenderWarning(fmt.Sprintf("  [dry run] cannot break the command down: %s", err)) + "\n"
+	}
+
+	return output + u.components.renderer.RenderContent(renderBreakdown(segments))
+}
+
+// renderBreakdown lists the stages of a command as markdown, with their flags and arguments
+func renderBreakdown(segments []run.Segment) string {
+	var sb strings.Builder
+
+	for i, segment := range segments {
+		sb.WriteString(fmt.Sprintf("%d. ", i+1))
+		if operator := segment.GetOperator(); operator != "" {
+			sb.WriteString(fmt.Sprintf("*%s* ", describeOperator(operator)))
+		}
+
+		if segment.GetName() == "" {
+			sb.WriteString(fmt.Sprintf("`%s`\n", segment.GetSource()))
+			continue
+		}
+
+		sb.WriteString(fmt.Sprintf("`%s`", segment.GetName()))
+		if flags := segment.GetFlags(); len(flags) > 0 {
+			sb.WriteString(fmt.Sprintf(", flags %s", renderCodeList(flags)))
+		}
+		if args := segment.GetArgs(); len(args) > 0 {
+			sb.WriteString(fmt.Sprintf(", arguments %s", renderCodeList(args)))
+		}
+		if redirects := segment.GetRedirects(); len(redirects) > 0 {
+			sb.WriteString(fmt.Sprintf(", redirections %s", renderCodeList(redirects)))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+func describeOperator(operator string) string {
+	switch operator {
+	case "|":
+		return "piped into"
+	case "|&":
+		return "piped with errors into"
+	case "&&":
+		return "on success"
+	case "||":
+		return "on failure"
+	case "&":
+		return "in parallel"
+	default:
+		return "then"
+	}
+}
+
+func renderCodeList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, value := range values {
+		quoted = append(quoted, fmt.Sprintf("`%s`", value))
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,122 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bmichalkiewicz/gogut/facts"
+	"github.com/bmichalkiewicz/gogut/run"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	editKey   = "e"
+	editorKey = "v"
+)
+
+// editedCommand is the command coming back from the editor
+type editedCommand struct {
+	command string
+	error   error
+}
+
+// startEdit puts the proposed command in the prompt to be edited in place
+func (u *UI) startEdit() tea.Cmd {
+	command := u.state.command
+	u.resetConfirmation()
+	u.state.editing = true
+	u.components.prompt.SetValue(command)
+	u.components.prompt.Focus()
+
+	var promptCmd tea.Cmd
+	// moves the cursor at the end of the command
+	u.components.prompt, promptCmd = u.components.prompt.Update(tea.KeyMsg{Type: tea.KeyEnd})
+
+	return tea.Batch(
+		promptCmd,
+		textinput.Blink,
+	)
+}
+
+// updateEdit handles the keys while the command is edited in the prompt,
+// enter asks to confirm the edited command again
+func (u *UI) updateEdit(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		command := strings.TrimSpace(u.components.prompt.GetValue())
+		u.state.editing = false
+		u.components.prompt.SetValue("")
+		if command == "" {
+			return u.rejectConfirmation()
+		}
+		return tea.Println(u.confirmCommand(command, ""))
+	case tea.KeyEsc, tea.KeyCtrlC:
+		u.state.editing = false
+		return u.rejectConfirmation()
+	}
+
+	var promptCmd tea.Cmd
+	u.components.prompt, promptCmd = u.components.prompt.Update(msg)
+
+	return promptCmd
+}
+
+// startEditor opens the proposed command in $EDITOR, for longer commands
+func (u *UI) startEditor() tea.Cmd {
+	command := u.state.command
+	u.resetConfirmation()
+	u.state.executing = true
+
+	file, err := os.CreateTemp("", "gogut-*.sh")
+	if err != nil {
+		return func() tea.Msg {
+			return editedCommand{error: err}
+		}
+	}
+	_, err = file.WriteString(command + "\n")
+	file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+		return func() tea.Msg {
+			return editedCommand{error: err}
+		}
+	}
+
+	c := run.PrepareEditSettingsCommand(u.getShell(), facts.GetEditor(), file.Name())
+
+	return tea.Exec(c, func(error error) tea.Msg {
+		defer os.Remove(file.Name())
+		u.state.executing = false
+
+		if error != nil {
+			return editedCommand{error: error}
+		}
+
+		content, error := os.ReadFile(file.Name())
+		if error != nil {
+			return editedCommand{error: error}
+		}
+
+		return editedCommand{command: strings.TrimSpace(string(content))}
+	})
+}
+
+// finishEditor asks to confirm the command edited in $EDITOR, an empty file cancels
+func (u *UI) finishEditor(msg editedCommand) tea.Cmd {
+	if msg.error != nil {
+		output := u.components.renderer.RenderError(fmt.Sprintf("\n[edit error]: %s\n", msg.error))
+		cancelCmd := u.rejectConfirmation()
+		return tea.Sequence(
+			tea.Println(output),
+			cancelCmd,
+		)
+	}
+
+	if msg.command == "" {
+		return u.rejectConfirmation()
+	}
+
+	return tea.Println(u.confirmCommand(msg.command, ""))
+}
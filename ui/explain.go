@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bmichalkiewicz/gogut/ai"
+	"github.com/bmichalkiewicz/gogut/run"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// promptModes are the prompt modes cycled through with tab in the REPL
+var promptModes = []PromptMode{ExecPromptMode, ChatPromptMode, ExplainPromptMode}
+
+// getNextPromptMode returns the prompt mode following the given one with tab
+func getNextPromptMode(mode PromptMode) PromptMode {
+	for i, m := range promptModes {
+		if m == mode {
+			return promptModes[(i+1)%len(promptModes)]
+		}
+	}
+
+	return ExecPromptMode
+}
+
+// getEngineMode returns the engine mode answering a prompt mode
+func getEngineMode(mode PromptMode) ai.EngineMode {
+	switch mode {
+	case ChatPromptMode:
+		return ai.ChatEngineMode
+	case ExplainPromptMode:
+		return ai.ExplainEngineMode
+	default:
+		return ai.ExecEngineMode
+	}
+}
+
+// startExplain asks the model to explain a command segment by segment, a
+// command the shell parser rejects is reported without calling the model
+func (u *UI) startExplain(input string) tea.Cmd {
+	if _, err := run.Breakdown(u.getShell(), input); err != nil {
+		output := u.components.renderer.RenderError(fmt.Sprintf("\n[explain] cannot parse the command: %s\n", err))
+		u.components.prompt.Focus()
+		if u.state.runMode == CliMode {
+			return tea.Sequence(
+				tea.Println(output),
+				tea.Quit,
+			)
+		}
+
+		return tea.Sequence(
+			tea.Println(output),
+			textinput.Blink,
+		)
+	}
+
+	return func() tea.Msg {
+		u.state.querying = true
+		u.state.buffer = ""
+		u.state.command = ""
+
+		output, err := u.engine.ExplainCompletion(context.Background(), input)
+		u.state.querying = false
+		if err != nil {
+			return err
+		}
+
+		return *output
+	}
+}
+
+// renderExplanation shows the local breakdown of the command, the explanation
+// of the model and the dangers found by the detector
+func (u *UI) renderExplanation(output ai.EngineExplainOutput) string {
+	var dangers []run.Danger
+	if u.detector != nil {
+		dangers = u.detector.Check(output.GetCommand())
+	}
+
+	return u.renderBackend(output.GetBackend()) + u.components.renderer.RenderContent(formatExplanation(output, dangers))
+}
+
+// formatExplanation puts the explanation of a command together as markdown
+func formatExplanation(output ai.EngineExplainOutput, dangers []run.Danger) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("`%s`\n\n", output.GetCommand()))
+	sb.WriteString("**Breakdown**\n\n")
+	sb.WriteString(renderBreakdown(output.GetSegments()))
+	sb.WriteString("\n")
+	sb.WriteString(output.GetContent())
+	sb.WriteString("\n")
+
+	if len(dangers) > 0 {
+		sb.WriteString("\n**Flagged as dangerous**\n\n")
+		for _, danger := range dangers {
+			sb.WriteString(fmt.Sprintf("- `%s`: %s\n", danger.GetName(), danger.GetDescription()))
+		}
+	}
+
+	return sb.String()
+}
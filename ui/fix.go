@@ -0,0 +1,164 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bmichalkiewicz/gogut/ai"
+	"github.com/bmichalkiewicz/gogut/config"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	fixKey = "f"
+	// defaultFixMaxAttempts is used when fix.max_attempts is not set
+	defaultFixMaxAttempts = 3
+	// maxCapturedOutput bounds the output kept from an executed command
+	maxCapturedOutput = 64 * 1024
+)
+
+// failedCommand is an executed command which failed, kept to ask for a fix
+type failedCommand struct {
+	command  string
+	exitCode int
+	// output is the error output, and stdout when it is not a terminal
+	output string
+}
+
+func (u *UI) getFixMaxAttempts() int {
+	if u.config == nil || u.config.GetFixConfig().GetMaxAttempts() <= 0 {
+		return defaultFixMaxAttempts
+	}
+
+	return u.config.GetFixConfig().GetMaxAttempts()
+}
+
+// offerFix asks whether the last failed command should be fixed by the model,
+// it returns an empty string when there is nothing to fix or no attempt left
+func (u *UI) offerFix() string {
+	if u.state.failed == nil {
+		return ""
+	}
+
+	if u.state.promptMode != ExecPromptMode || u.engine == nil {
+		u.state.failed = nil
+		return ""
+	}
+
+	if u.state.attempts >= u.getFixMaxAttempts() {
+		u.state.failed = nil
+		return u.components.renderer.RenderHelp(fmt.Sprintf("  [fix] gave up after %d attempts", u.state.attempts))
+	}
+
+	u.state.fixing = true
+	u.components.prompt.Blur()
+
+	return fmt.Sprintf(
+		"  ask gogut to fix it? [%s/N] %s",
+		fixKey,
+		u.components.renderer.RenderHelp(fmt.Sprintf("(attempt %d/%d)", u.state.attempts+1, u.getFixMaxAttempts())),
+	)
+}
+
+func (u *UI) updateFix(msg tea.KeyMsg) tea.Cmd {
+	switch strings.ToLower(msg.String()) {
+	case fixKey, "y":
+		return u.startFix()
+	}
+
+	return u.declineFix()
+}
+
+// startFix sends the failed command, its exit code and output to the model
+// for a corrected command, which goes through the usual confirmation
+func (u *UI) startFix() tea.Cmd {
+	failed := u.state.failed
+	u.state.fixing = false
+	u.state.failed = nil
+	u.state.attempts++
+	u.state.querying = true
+
+	return tea.Batch(
+		u.components.spinner.Tick,
+		func() tea.Msg {
+			output, err := u.engine.FixCompletion(context.Background(), failed.command, failed.exitCode, failed.output)
+			u.state.querying = false
+			if err != nil {
+				return err
+			}
+
+			return *output
+		},
+	)
+}
+
+func (u *UI) declineFix() tea.Cmd {
+	u.state.fixing = false
+	u.state.failed = nil
+	u.components.prompt.Focus()
+
+	if u.state.runMode == CliMode {
+		return tea.Quit
+	}
+
+	return textinput.Blink
+}
+
+// runFix asks for a corrected version of the command given to gogut fix, its
+// output is read from stdin only when piped by hand, the shell-init hook only
+// sends the command and its exit code, gogut exits with the status of the
+// corrected command, or 1 when none was run
+func runFix(input *UIInput) int {
+	if strings.TrimSpace(input.GetArgs()) == "" {
+		fmt.Fprintf(os.Stderr, "usage: gogut %s [--exit-code code] -- <command>\n", fixSubcommand)
+		return exitUsage
+	}
+
+	model, err := tea.NewProgram(NewUI(input)).Run()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return exitError
+	}
+
+	if ui, ok := model.(*UI); ok {
+		return ui.state.status
+	}
+
+	return exitError
+}
+
+// startFixCli fixes the command given to gogut fix without any conversation,
+// with the dedicated system prompt, the corrected command is confirmed as usual
+func (u *UI) startFixCli(config *config.Config) tea.Cmd {
+	u.config = config
+	u.state.promptMode = ExecPromptMode
+	u.state.runMode = CliMode
+
+	// the piped data is the output of the failed command, not a context for the prompt
+	failed := &failedCommand{
+		command:  strings.TrimSpace(u.state.args),
+		exitCode: u.state.exitCode,
+		output:   u.state.pipe,
+	}
+	u.state.pipe = ""
+
+	engine, err := u.newEngine(ai.ExecEngineMode, config)
+	if err != nil {
+		u.state.error = err
+		return nil
+	}
+
+	u.engine = engine.SetFix(true)
+	u.state.prompt = failed.command
+	u.state.failed = failed
+	u.loadHistories(config)
+
+	return tea.Batch(
+		u.loadSafetyRules(config),
+		u.loadPolicy(config),
+		u.startFix(),
+	)
+}
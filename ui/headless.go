@@ -0,0 +1,281 @@
+package ui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/bmichalkiewicz/gogut/ai"
+	"github.com/bmichalkiewicz/gogut/config"
+	"github.com/bmichalkiewicz/gogut/facts"
+	"github.com/bmichalkiewicz/gogut/run"
+)
+
+const (
+	jsonOutput = "json"
+	textOutput = "text"
+	rawOutput  = "raw"
+)
+
+var outputFormats = []string{jsonOutput, textOutput, rawOutput}
+
+// exit codes of the headless mode, for scripts
+const (
+	exitOK            = 0
+	exitError         = 1
+	exitUsage         = 2
+	exitNotExecutable = 3
+	exitConfig        = 4
+	exitInterrupted   = 130
+)
+
+func isOutputFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+
+	for _, f := range outputFormats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// execJSONOutput is the exec answer printed with --output json
+type execJSONOutput struct {
+	Command     string   `json:"cmd"`
+	Explanation string   `json:"exp"`
+	Executable  bool     `json:"exec"`
+	Model       string   `json:"model"`
+	Usage       ai.Usage `json:"usage"`
+	Cached      bool     `json:"cached"`
+}
+
+// explainJSONOutput is the explanation printed with --output json
+type explainJSONOutput struct {
+	Command string   `json:"cmd"`
+	Content string   `json:"content"`
+	Dangers []string `json:"dangers"`
+	Model   string   `json:"model"`
+	Usage   ai.Usage `json:"usage"`
+}
+
+// chatJSONOutput is the chat answer printed with --output json
+type chatJSONOutput struct {
+	Content string `json:"content"`
+	Model   string `json:"model"`
+}
+
+// RunHeadless answers the prompt without the interactive interface, so the
+// output stays clean when it is not a terminal, and returns the exit code:
+// 1 on error, 2 on usage error, 3 when no command could be generated, 4
+// without configuration and 130 when interrupted
+func RunHeadless(input *UIInput) int {
+	if !isOutputFormat(input.GetOutput()) {
+		fmt.Fprintf(os.Stderr, "error: invalid output %q, expected %s\n", input.GetOutput(), strings.Join(outputFormats, ", "))
+		return exitUsage
+	}
+
+	if input.GetArgs() == "" {
+		fmt.Fprintln(os.Stderr, "error: a prompt is required")
+		return exitUsage
+	}
+
+	conf, err := config.NewConfig(facts.GetConfigFile())
+	if err != nil {
+		if errors.Is(err, config.ConfigFileNotfoundError{}) {
+			fmt.Fprintln(os.Stderr, "error: no configuration found, run gogut once to set it up")
+			return exitConfig
+		}
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return exitConfig
+	}
+
+	format := input.GetOutput()
+	if format == "" {
+		format = rawOutput
+	}
+
+	promptMode := input.GetPromptMode()
+	if promptMode == DefaultPromptMode {
+		promptMode = GetPromptModeFromString(conf.GetUserConfig().GetDefaultPromptMode())
+	}
+	// a dry run only makes sense for commands
+	if input.IsDryRun() {
+		promptMode = ExecPromptMode
+	}
+
+	engineMode := getEngineMode(promptMode)
+
+	engine, err := newEngine(engineMode, conf, input.GetPipe(), input.GetSampling(), input.IsNoCache())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return exitConfig
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if engineMode == ai.ChatEngineMode {
+		return runHeadlessChat(ctx, engine, input.GetArgs(), format, os.Stdout)
+	}
+
+	if engineMode == ai.ExplainEngineMode {
+		detector, errs := newDetector(conf)
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", err)
+		}
+		return runHeadlessExplain(ctx, engine, detector, input.GetArgs(), format, os.Stdout)
+	}
+
+	return runHeadlessExec(ctx, engine, input.GetArgs(), format, os.Stdout)
+}
+
+func runHeadlessExec(ctx context.Context, engine *ai.Engine, prompt, format string, w io.Writer) int {
+	output, err := engine.ExecCompletion(ctx, prompt)
+	if err != nil {
+		return reportHeadlessError(ctx, err)
+	}
+
+	for _, warning := range output.GetWarnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+
+	code := exitOK
+	if !output.IsExecutable() {
+		code = exitNotExecutable
+	}
+
+	switch format {
+	case jsonOutput:
+		content, err := json.Marshal(execJSONOutput{
+			Command:     output.GetCommand(),
+			Explanation: output.GetExplanation(),
+			Executable:  output.IsExecutable(),
+			Model:       output.GetModel(),
+			Usage:       output.GetUsage(),
+			Cached:      output.IsCached(),
+		})
+		if err != nil {
+			return reportHeadlessError(ctx, err)
+		}
+		fmt.Fprintln(w, string(content))
+	case textOutput:
+		if output.IsExecutable() {
+			fmt.Fprintln(w, output.GetCommand())
+			fmt.Fprintln(w, output.GetExplanation())
+		} else {
+			fmt.Fprintln(os.Stderr, output.GetExplanation())
+		}
+	default:
+		if output.IsExecutable() {
+			fmt.Fprintln(w, output.GetCommand())
+		} else {
+			fmt.Fprintln(os.Stderr, output.GetExplanation())
+		}
+	}
+
+	return code
+}
+
+// runHeadlessExplain prints the explanation of the model alone as raw, with
+// the breakdown and the dangers as text
+func runHeadlessExplain(ctx context.Context, engine *ai.Engine, detector *run.Detector, command, format string, w io.Writer) int {
+	output, err := engine.ExplainCompletion(ctx, command)
+	if err != nil {
+		return reportHeadlessError(ctx, err)
+	}
+
+	dangers := detector.Check(command)
+
+	switch format {
+	case jsonOutput:
+		names := make([]string, 0, len(dangers))
+		for _, danger := range dangers {
+			names = append(names, danger.GetName())
+		}
+		content, err := json.Marshal(explainJSONOutput{
+			Command: output.GetCommand(),
+			Content: output.GetContent(),
+			Dangers: names,
+			Model:   output.GetModel(),
+			Usage:   output.GetUsage(),
+		})
+		if err != nil {
+			return reportHeadlessError(ctx, err)
+		}
+		fmt.Fprintln(w, string(content))
+	case textOutput:
+		fmt.Fprint(w, formatExplanation(*output, dangers))
+	default:
+		fmt.Fprintln(w, output.GetContent())
+	}
+
+	return exitOK
+}
+
+// runHeadlessChat prints the answer as it is streamed, or at once as json
+func runHeadlessChat(ctx context.Context, engine *ai.Engine, prompt, format string, w io.Writer) int {
+	errs := make(chan error, 1)
+	go func() {
+		errs <- engine.ChatStreamCompletion(ctx, prompt)
+	}()
+
+	var content string
+	for {
+		select {
+		case output := <-engine.GetChannel():
+			if output.IsInterrupt() {
+				fmt.Fprintln(os.Stderr, "interrupted")
+				return exitInterrupted
+			}
+
+			content += output.GetContent()
+			if format != jsonOutput {
+				fmt.Fprint(w, output.GetContent())
+			}
+
+			if !output.IsLast() {
+				continue
+			}
+
+			if format != jsonOutput {
+				fmt.Fprintln(w)
+				return exitOK
+			}
+
+			encoded, err := json.Marshal(chatJSONOutput{
+				Content: content,
+				Model:   output.GetModel(),
+			})
+			if err != nil {
+				return reportHeadlessError(ctx, err)
+			}
+			fmt.Fprintln(w, string(encoded))
+
+			return exitOK
+		case err := <-errs:
+			if err != nil {
+				return reportHeadlessError(ctx, err)
+			}
+		}
+	}
+}
+
+func reportHeadlessError(ctx context.Context, err error) int {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		fmt.Fprintln(os.Stderr, "interrupted")
+		return exitInterrupted
+	}
+
+	fmt.Fprintf(os.Stderr, "error: %s\n", err)
+
+	return exitError
+}
@@ -0,0 +1,237 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bmichalkiewicz/gogut/ai"
+	"github.com/bmichalkiewicz/gogut/config"
+	"github.com/mitchellh/go-homedir"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider answers with a fixed content, streamed in chunks, or fails
+type fakeProvider struct {
+	content string
+	chunks  []string
+	err     error
+}
+
+func (p *fakeProvider) Complete(ctx context.Context, req ai.Request) (*ai.Response, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return &ai.Response{Content: p.content}, nil
+}
+
+func (p *fakeProvider) Stream(ctx context.Context, req ai.Request) (ai.Stream, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return &fakeStream{chunks: p.chunks}, nil
+}
+
+func (p *fakeProvider) ListModels(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+type fakeStream struct {
+	chunks []string
+}
+
+func (s *fakeStream) Recv() (string, error) {
+	if len(s.chunks) == 0 {
+		return "", io.EOF
+	}
+
+	chunk := s.chunks[0]
+	s.chunks = s.chunks[1:]
+
+	return chunk, nil
+}
+
+func (s *fakeStream) Close() error {
+	return nil
+}
+
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "settings:\n  model: test_model\n  retries: 0\n  timeout: 0s\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	cfg, err := config.NewConfig(path)
+	require.NoError(t, err)
+
+	return cfg
+}
+
+func TestRunHeadlessExec(t *testing.T) {
+	executable := `{"cmd":"ls -la", "exp": "list files", "exec": true}`
+
+	tests := []struct {
+		name        string
+		provider    *fakeProvider
+		format      string
+		interrupted bool
+		expected    int
+		output      string
+	}{
+		{
+			name:     "raw",
+			provider: &fakeProvider{content: executable},
+			format:   rawOutput,
+			expected: exitOK,
+			output:   "ls -la\n",
+		},
+		{
+			name:     "text",
+			provider: &fakeProvider{content: executable},
+			format:   textOutput,
+			expected: exitOK,
+			output:   "ls -la\nlist files\n",
+		},
+		{
+			name:     "json",
+			provider: &fakeProvider{content: executable},
+			format:   jsonOutput,
+			expected: exitOK,
+			output:   `{"cmd":"ls -la","exp":"list files","exec":true,"model":"test_model","usage":{"prompt_tokens":0,"completion_tokens":0},"cached":false}` + "\n",
+		},
+		{
+			name:     "not executable",
+			provider: &fakeProvider{content: `{"cmd":"", "exp": "no such command", "exec": false}`},
+			format:   rawOutput,
+			expected: exitNotExecutable,
+			output:   "",
+		},
+		{
+			name:     "provider error",
+			provider: &fakeProvider{err: errors.New("connection refused")},
+			format:   rawOutput,
+			expected: exitError,
+			output:   "",
+		},
+		{
+			name:        "interrupted",
+			provider:    &fakeProvider{content: executable},
+			format:      rawOutput,
+			interrupted: true,
+			expected:    exitInterrupted,
+			output:      "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := ai.NewEngineWithProvider(ai.ExecEngineMode, newTestConfig(t), test.provider)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.interrupted {
+				cancel()
+			}
+
+			var output bytes.Buffer
+			assert.Equal(t, test.expected, runHeadlessExec(ctx, engine, "list files", test.format, &output))
+			assert.Equal(t, test.output, output.String())
+		})
+	}
+}
+
+func TestRunHeadlessChat(t *testing.T) {
+	chunks := []string{"Hello", " there"}
+
+	tests := []struct {
+		name        string
+		provider    *fakeProvider
+		format      string
+		interrupted bool
+		expected    int
+		output      string
+	}{
+		{
+			name:     "raw",
+			provider: &fakeProvider{chunks: chunks},
+			format:   rawOutput,
+			expected: exitOK,
+			output:   "Hello there\n",
+		},
+		{
+			name:     "json",
+			provider: &fakeProvider{chunks: chunks},
+			format:   jsonOutput,
+			expected: exitOK,
+			output:   `{"content":"Hello there","model":"test_model"}` + "\n",
+		},
+		{
+			name:     "provider error",
+			provider: &fakeProvider{err: errors.New("connection refused")},
+			format:   rawOutput,
+			expected: exitError,
+			output:   "",
+		},
+		{
+			name:        "interrupted",
+			provider:    &fakeProvider{chunks: chunks},
+			format:      rawOutput,
+			interrupted: true,
+			expected:    exitInterrupted,
+			output:      "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			engine := ai.NewEngineWithProvider(ai.ChatEngineMode, newTestConfig(t), test.provider)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.interrupted {
+				cancel()
+			}
+
+			var output bytes.Buffer
+			assert.Equal(t, test.expected, runHeadlessChat(ctx, engine, "hello", test.format, &output))
+			assert.Equal(t, test.output, output.String())
+		})
+	}
+}
+
+func TestRunHeadless(t *testing.T) {
+	t.Run("InvalidOutput", func(t *testing.T) {
+		input := &UIInput{runMode: CliMode, args: "list files", output: "yaml"}
+
+		assert.Equal(t, exitUsage, RunHeadless(input))
+	})
+
+	t.Run("MissingPrompt", func(t *testing.T) {
+		input := &UIInput{runMode: CliMode, output: rawOutput}
+
+		assert.Equal(t, exitUsage, RunHeadless(input))
+	})
+
+	t.Run("MissingConfig", func(t *testing.T) {
+		homedir.DisableCache = true
+		t.Setenv("HOME", t.TempDir())
+
+		input := &UIInput{runMode: CliMode, args: "list files", output: rawOutput}
+
+		assert.Equal(t, exitConfig, RunHeadless(input))
+	})
+}
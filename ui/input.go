@@ -6,15 +6,24 @@ import (
 	"os"
 	"strings"
 
+	"github.com/bmichalkiewicz/gogut/ai"
 	"github.com/charmbracelet/log"
 	flag "github.com/spf13/pflag"
 )
 
 type UIInput struct {
+	subcommand string
 	runMode    RunMode
 	promptMode PromptMode
 	args       string
 	pipe       string
+	sampling   ai.Sampling
+	resume     bool
+	noCache    bool
+	dryRun     bool
+	yes        bool
+	exitCode   int
+	output     string
 }
 
 func getPipeData() (string, error) {
@@ -47,7 +56,16 @@ func NewUIInput() (*UIInput, error) {
 
 	exec := flags.Bool("exec", false, "Run with exec mode")
 	chat := flags.Bool("prompt", false, "Run with chat mode")
+	explain := flags.Bool("explain", false, "Run with explain mode, explaining the given command")
 	debug := flags.Bool("debug", false, "Debug mode")
+	temperature := flags.Float64("temperature", 0, "Sampling temperature for this run")
+	seed := flags.Int("seed", 0, "Sampling seed for this run")
+	resume := flags.Bool("resume", false, "Resume the last chat session")
+	noCache := flags.Bool("no-cache", false, "Ask the model even if the answer is cached")
+	dryRun := flags.Bool("dry-run", false, "Print the command without executing it, only the command in cli mode")
+	yes := flags.Bool("yes", false, "Execute without confirmation the commands allowed by the policy file")
+	exitCode := flags.Int("exit-code", 1, "Exit code of the command given to gogut fix")
+	output := flags.String("output", "", "Print the answer without the interactive interface, as json, text or raw")
 
 	err := flags.Parse(os.Args[1:])
 	if err != nil {
@@ -58,8 +76,21 @@ func NewUIInput() (*UIInput, error) {
 		log.SetLevel(log.DebugLevel)
 	}
 
+	var sampling ai.Sampling
+	if flags.Changed("temperature") {
+		sampling.Temperature = temperature
+	}
+	if flags.Changed("seed") {
+		sampling.Seed = seed
+	}
+
 	args := flags.Args()
 
+	subcommand := getSubcommand(args, flags.ArgsLenAtDash())
+	if subcommand != "" {
+		args = args[1:]
+	}
+
 	runMode := ReplMode
 	if len(args) > 0 {
 		runMode = CliMode
@@ -68,6 +99,8 @@ func NewUIInput() (*UIInput, error) {
 	var promptMode PromptMode
 
 	switch {
+	case *explain && !*exec && !*chat:
+		promptMode = ExplainPromptMode
 	case !*exec && *chat:
 		promptMode = ChatPromptMode
 	case *exec && !*chat:
@@ -82,13 +115,26 @@ func NewUIInput() (*UIInput, error) {
 	}
 
 	return &UIInput{
+		subcommand: subcommand,
 		runMode:    runMode,
 		promptMode: promptMode,
 		args:       strings.Join(args, " "),
 		pipe:       pipe,
+		sampling:   sampling,
+		resume:     *resume,
+		noCache:    *noCache,
+		dryRun:     *dryRun,
+		yes:        *yes,
+		exitCode:   *exitCode,
+		output:     *output,
 	}, nil
 }
 
+// GetSubcommand returns the subcommand given as first argument, eg. shell-init
+func (i *UIInput) GetSubcommand() string {
+	return i.subcommand
+}
+
 func (i *UIInput) GetRunMode() RunMode {
 	return i.runMode
 }
@@ -104,3 +150,39 @@ func (i *UIInput) GetArgs() string {
 func (i *UIInput) GetPipe() string {
 	return i.pipe
 }
+
+func (i *UIInput) GetSampling() ai.Sampling {
+	return i.sampling
+}
+
+func (i *UIInput) IsResume() bool {
+	return i.resume
+}
+
+func (i *UIInput) IsNoCache() bool {
+	return i.noCache
+}
+
+func (i *UIInput) IsDryRun() bool {
+	return i.dryRun
+}
+
+// IsYes tells if the commands allowed by the policy file run without confirmation
+func (i *UIInput) IsYes() bool {
+	return i.yes
+}
+
+// GetExitCode returns the exit code of the command given to gogut fix
+func (i *UIInput) GetExitCode() int {
+	return i.exitCode
+}
+
+// GetOutput returns the output format asked with --output, empty for the interactive interface
+func (i *UIInput) GetOutput() string {
+	return i.output
+}
+
+// IsHeadless tells if the prompt is answered without the interactive interface
+func (i *UIInput) IsHeadless() bool {
+	return i.output != "" || (i.dryRun && i.runMode == CliMode)
+}
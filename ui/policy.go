@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/bmichalkiewicz/gogut/config"
+	"github.com/bmichalkiewicz/gogut/facts"
+	"github.com/bmichalkiewicz/gogut/run"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// loadPolicy reads the commands allowed to run without confirmation, only with --yes
+func (u *UI) loadPolicy(config *config.Config) tea.Cmd {
+	if !u.state.yes {
+		return nil
+	}
+
+	policy, err := newPolicy(facts.GetPolicyFile(), run.GetShell(config.GetSystemConfig().GetShell()))
+	u.policy = policy
+	if err != nil {
+		return tea.Println(u.components.renderer.RenderWarning(fmt.Sprintf("  [policy] %s, every command needs a confirmation", err)) + "\n")
+	}
+	if policy.IsEmpty() {
+		return tea.Println(u.components.renderer.RenderWarning(fmt.Sprintf("  [policy] no command allowed in %s, every command needs a confirmation", facts.GetPolicyFile())) + "\n")
+	}
+
+	return nil
+}
+
+func newPolicy(policyFile string, shell run.Shell) (*run.Policy, error) {
+	policyConfig, err := config.LoadPolicyConfig(policyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := run.NewPolicy(policyConfig.GetPrefixes(), policyConfig.GetPatterns())
+	if err != nil {
+		return nil, err
+	}
+
+	return policy.SetShell(shell), nil
+}
+
+// isAllowed tells if a command can run without confirmation, dangerous
+// commands always need one even when the policy allows them
+func (u *UI) isAllowed(command string) bool {
+	if !u.state.yes || u.policy == nil || !u.policy.Allows(command) {
+		return false
+	}
+
+	return u.detector == nil || len(u.detector.Check(command)) == 0
+}
+
+// allowCommand runs a command allowed by the policy, as if it was confirmed
+func (u *UI) allowCommand(command, explanation string) (string, tea.Cmd) {
+	u.state.command = command
+	u.components.prompt.Blur()
+
+	output := u.components.renderer.RenderContent(fmt.Sprintf("`%s`", command))
+	if explanation != "" {
+		output += fmt.Sprintf("  %s\n\n", u.components.renderer.RenderHelp(explanation))
+	}
+	output += u.components.renderer.RenderHelp(fmt.Sprintf("  [allowed by %s, executing with %s]", facts.GetPolicyFile(), u.getShell()))
+
+	return output, u.acceptConfirmation()
+}
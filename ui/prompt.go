@@ -9,12 +9,14 @@ import (
 )
 
 const (
-	execIcon          = "🚀 > "
-	execPlaceholder   = "Execute something..."
-	configIcon        = "🔒 > "
-	configPlaceholder = "Enter your API key..."
-	chatIcon          = "💬 > "
-	chatPlaceholder   = "Ask me something..."
+	execIcon           = "🚀 > "
+	execPlaceholder    = "Execute something..."
+	configIcon         = "🔒 > "
+	configPlaceholder  = "Enter your API key..."
+	chatIcon           = "💬 > "
+	chatPlaceholder    = "Ask me something..."
+	explainIcon        = "🔎 > "
+	explainPlaceholder = "Paste a command to explain..."
 )
 
 type Prompt struct {
@@ -99,6 +101,8 @@ func getPromptStyle(mode PromptMode) lipgloss.Style {
 		return lipgloss.NewStyle().Foreground(lipgloss.Color(execColor))
 	case ConfigPromptMode:
 		return lipgloss.NewStyle().Foreground(lipgloss.Color(configColor))
+	case ExplainPromptMode:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(scriptColor))
 	default:
 		return lipgloss.NewStyle().Foreground(lipgloss.Color(chatColor))
 	}
@@ -112,6 +116,8 @@ func getPromptIcon(mode PromptMode) string {
 		return style.Render(execIcon)
 	case ConfigPromptMode:
 		return style.Render(configIcon)
+	case ExplainPromptMode:
+		return style.Render(explainIcon)
 	default:
 		return style.Render(chatIcon)
 	}
@@ -123,6 +129,8 @@ func getPromptPlaceholder(mode PromptMode) string {
 		return execPlaceholder
 	case ConfigPromptMode:
 		return configPlaceholder
+	case ExplainPromptMode:
+		return explainPlaceholder
 	default:
 		return chatPlaceholder
 	}
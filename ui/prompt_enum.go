@@ -5,6 +5,7 @@ type PromptMode int
 const (
 	ExecPromptMode PromptMode = iota
 	ChatPromptMode
+	ExplainPromptMode
 	ConfigPromptMode
 	DefaultPromptMode
 )
@@ -15,6 +16,8 @@ func (pm PromptMode) String() string {
 		return "exec"
 	case ChatPromptMode:
 		return "chat"
+	case ExplainPromptMode:
+		return "explain"
 	case ConfigPromptMode:
 		return "config"
 	default:
@@ -28,6 +31,8 @@ func GetPromptModeFromString(s string) PromptMode {
 		return ExecPromptMode
 	case "chat":
 		return ChatPromptMode
+	case "explain":
+		return ExplainPromptMode
 	case "config":
 		return ConfigPromptMode
 	default:
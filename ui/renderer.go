@@ -24,6 +24,7 @@ type Renderer struct {
 	warningRenderer lipgloss.Style
 	errorRenderer   lipgloss.Style
 	helpRenderer    lipgloss.Style
+	matchRenderer   lipgloss.Style
 }
 
 func NewRenderer(options ...glamour.TermRendererOption) *Renderer {
@@ -36,6 +37,7 @@ func NewRenderer(options ...glamour.TermRendererOption) *Renderer {
 	warningRenderer := lipgloss.NewStyle().Foreground(lipgloss.Color(warningColor))
 	errorRenderer := lipgloss.NewStyle().Foreground(lipgloss.Color(errorColor))
 	helpRenderer := lipgloss.NewStyle().Foreground(lipgloss.Color(helpColor)).Italic(true)
+	matchRenderer := lipgloss.NewStyle().Foreground(lipgloss.Color(warningColor)).Bold(true)
 
 	return &Renderer{
 		contentRenderer: contentRenderer,
@@ -43,6 +45,7 @@ func NewRenderer(options ...glamour.TermRendererOption) *Renderer {
 		warningRenderer: warningRenderer,
 		errorRenderer:   errorRenderer,
 		helpRenderer:    helpRenderer,
+		matchRenderer:   matchRenderer,
 	}
 }
 
@@ -68,6 +71,25 @@ func (r *Renderer) RenderHelp(in string) string {
 	return r.helpRenderer.Render(in)
 }
 
+// RenderMatch highlights the runes of in at the given positions
+func (r *Renderer) RenderMatch(in string, positions []int) string {
+	highlighted := map[int]bool{}
+	for _, position := range positions {
+		highlighted[position] = true
+	}
+
+	var sb strings.Builder
+	for i, char := range []rune(in) {
+		if highlighted[i] {
+			sb.WriteString(r.matchRenderer.Render(string(char)))
+		} else {
+			sb.WriteRune(char)
+		}
+	}
+
+	return sb.String()
+}
+
 func (r *Renderer) RenderConfigMessage() string {
 	var sb strings.Builder
 
@@ -83,12 +105,17 @@ func (r *Renderer) RenderHelpMessage() string {
 
 	sb.WriteString("**Help**\n")
 	sb.WriteString("- `↑`/`↓` : navigate in history\n")
-	sb.WriteString("- `tab`   : switch between `🚀 exec` and `💬 chat` prompt modes\n")
+	sb.WriteString("- `tab`   : switch between `🚀 exec`, `💬 chat` and `🔎 explain` prompt modes\n")
 	sb.WriteString("- `ctrl+h`: show help\n")
 	sb.WriteString("- `ctrl+s`: edit settings\n")
-	sb.WriteString("- `ctrl+r`: clear terminal and reset discussion history\n")
+	sb.WriteString("- `ctrl+r`: search in history, `ctrl+r` again for older matches, `enter` to pick, `esc` to cancel\n")
+	sb.WriteString("- `ctrl+x`: clear terminal and reset discussion history\n")
 	sb.WriteString("- `ctrl+l`: clear terminal but keep discussion history\n")
-	sb.WriteString("- `ctrl+c`: exit or interrupt command execution\n")
+	sb.WriteString("- `ctrl+c`: interrupt the current request or command, exit otherwise\n")
+	sb.WriteString("- `/summary`: show the summary of the older chat turns\n")
+	sb.WriteString("- `/commands [query]`: list the executed commands, matching the query if any\n")
+	sb.WriteString("- `/rerun <number>`: run a listed command again, without asking the model\n")
+	sb.WriteString("- `/dry-run`: toggle the dry run, commands are broken down but never executed\n")
 
 	return sb.String()
 }
@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bmichalkiewicz/gogut/config"
+	"github.com/bmichalkiewicz/gogut/facts"
+	"github.com/bmichalkiewicz/gogut/run"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const dangerousConfirmation = "yes"
+
+// loadSafetyRules builds the dangerous command detector from the built in
+// rules and the configured ones, invalid rules are reported and skipped
+func (u *UI) loadSafetyRules(config *config.Config) tea.Cmd {
+	detector, errs := newDetector(config)
+	u.detector = detector
+
+	if len(errs) == 0 {
+		return nil
+	}
+
+	var output string
+	for _, err := range errs {
+		output += u.components.renderer.RenderWarning(fmt.Sprintf("  [safety] %s", err)) + "\n"
+	}
+
+	return tea.Println(output)
+}
+
+func newDetector(config *config.Config) (*run.Detector, []error) {
+	var (
+		rules []run.Rule
+		errs  []error
+	)
+	for _, rule := range config.GetSafetyConfig().GetRules() {
+		r, err := run.NewPatternRule(rule.GetName(), rule.GetPattern(), rule.GetDescription())
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rules = append(rules, r)
+	}
+
+	return run.NewDetector(rules...).SetShell(run.GetShell(config.GetSystemConfig().GetShell())), errs
+}
+
+// confirmCommand asks to confirm the execution of a command, dangerous
+// commands are flagged in red and need yes to be typed instead of y
+func (u *UI) confirmCommand(command, explanation string) string {
+	u.state.confirming = true
+	u.state.command = command
+	u.state.confirmation = ""
+	u.state.dangerous = false
+	u.components.prompt.Blur()
+
+	output := u.components.renderer.RenderContent(fmt.Sprintf("`%s`", command))
+	if explanation != "" {
+		output += fmt.Sprintf("  %s\n\n", u.components.renderer.RenderHelp(explanation))
+	}
+
+	var dangers []run.Danger
+	if u.detector != nil {
+		dangers = u.detector.Check(command)
+	}
+	if len(dangers) == 0 {
+		return output + fmt.Sprintf("  confirm execution with %s? [y/N, %s to edit, %s to open in %s]", u.getShell(), editKey, editorKey, facts.GetEditor())
+	}
+
+	u.state.dangerous = true
+	for _, danger := range dangers {
+		output += u.components.renderer.RenderError(fmt.Sprintf("  [danger] %s: %s", danger.GetName(), danger.GetDescription())) + "\n"
+	}
+
+	return output + fmt.Sprintf(
+		"\n  this command is dangerous, type %s to confirm execution with %s [%s to edit, %s to open in %s]",
+		dangerousConfirmation,
+		u.getShell(),
+		editKey,
+		editorKey,
+		facts.GetEditor(),
+	)
+}
+
+// updateDangerousConfirmation collects the typed confirmation, anything but
+// yes or an edit option followed by enter cancels the execution
+func (u *UI) updateDangerousConfirmation(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEnter:
+		switch strings.ToLower(strings.TrimSpace(u.state.confirmation)) {
+		case dangerousConfirmation:
+			return u.acceptConfirmation()
+		case editKey:
+			return u.startEdit()
+		case editorKey:
+			return u.startEditor()
+		}
+		return u.rejectConfirmation()
+	case tea.KeyEsc:
+		return u.rejectConfirmation()
+	case tea.KeyBackspace:
+		confirmation := []rune(u.state.confirmation)
+		if len(confirmation) > 0 {
+			u.state.confirmation = string(confirmation[:len(confirmation)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		u.state.confirmation += string(msg.Runes)
+	}
+
+	return nil
+}
+
+func (u *UI) renderDangerousConfirmation() string {
+	return fmt.Sprintf("  %s %s", u.components.renderer.RenderError(">"), u.state.confirmation)
+}
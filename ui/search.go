@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/bmichalkiewicz/gogut/history"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func (u *UI) startSearch() {
+	u.state.searching = true
+	u.search = history.NewSearch(u.history)
+	u.components.prompt.Blur()
+}
+
+// updateSearch handles the keys while the reverse search overlay is shown,
+// like readline ctrl+r cycles through older matches
+func (u *UI) updateSearch(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyCtrlR:
+		u.search.Next()
+	case tea.KeyCtrlS:
+		u.search.Previous()
+	case tea.KeyEnter, tea.KeyRight, tea.KeyLeft, tea.KeyEnd, tea.KeyHome:
+		if match := u.search.GetMatch(); match != nil {
+			u.components.prompt.SetValue(match.GetInput())
+		}
+		return u.finishSearch()
+	case tea.KeyEsc, tea.KeyCtrlG, tea.KeyCtrlC:
+		return u.finishSearch()
+	case tea.KeyBackspace:
+		query := []rune(u.search.GetQuery())
+		if len(query) > 0 {
+			u.search.SetQuery(string(query[:len(query)-1]))
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		u.search.SetQuery(u.search.GetQuery() + string(msg.Runes))
+	}
+
+	return nil
+}
+
+func (u *UI) finishSearch() tea.Cmd {
+	u.state.searching = false
+	u.search = nil
+	u.components.prompt.Focus()
+
+	var promptCmd tea.Cmd
+	// moves the cursor at the end of the picked input
+	u.components.prompt, promptCmd = u.components.prompt.Update(tea.KeyMsg{Type: tea.KeyEnd})
+
+	return tea.Batch(
+		promptCmd,
+		textinput.Blink,
+	)
+}
+
+func (u *UI) renderSearch() string {
+	label := fmt.Sprintf("(reverse-i-search)`%s': ", u.search.GetQuery())
+
+	match := u.search.GetMatch()
+	if match == nil {
+		if u.search.GetQuery() != "" {
+			label = fmt.Sprintf("(failed reverse-i-search)`%s': ", u.search.GetQuery())
+		}
+		return u.components.renderer.RenderHelp(label)
+	}
+
+	return u.components.renderer.RenderHelp(label) + u.components.renderer.RenderMatch(match.GetInput(), match.GetPositions())
+}
@@ -0,0 +1,91 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bmichalkiewicz/gogut/run"
+)
+
+// the widgets send the command line to gogut, print only the generated
+// command and put it back in the command line, to be edited or run by the
+// shell itself so it lands in its history, errors are printed on stderr,
+// gogut_fix sends the last command and its exit code to gogut fix
+const (
+	bashWidget = `_gogut_widget() {
+  [[ -z "$READLINE_LINE" ]] && return
+  local generated
+  generated=$(%[1]s --exec --output raw -- "$READLINE_LINE" </dev/null) || return
+  READLINE_LINE=$generated
+  READLINE_POINT=${#READLINE_LINE}
+}
+bind -x '"\C-g": _gogut_widget'
+
+gogut_fix() {
+  local code=$?
+  %[1]s fix --exit-code "$code" -- "$(fc -ln -1)"
+}
+`
+
+	zshWidget = `_gogut_widget() {
+  [[ -z "$BUFFER" ]] && return
+  local generated
+  zle -I
+  generated=$(%[1]s --exec --output raw -- "$BUFFER" </dev/null) || return
+  BUFFER=$generated
+  CURSOR=${#BUFFER}
+}
+zle -N _gogut_widget
+bindkey '^G' _gogut_widget
+
+gogut_fix() {
+  local code=$?
+  %[1]s fix --exit-code "$code" -- "$(fc -ln -1)"
+}
+`
+
+	fishWidget = `function _gogut_widget
+    set -l buffer (commandline)
+    if test -z "$buffer"
+        return
+    end
+    set -l generated (%[1]s --exec --output raw -- "$buffer" </dev/null)
+    and commandline -r -- (string join \n -- $generated)
+    commandline -f repaint
+end
+bind \cg _gogut_widget
+
+function gogut_fix
+    set -l code $status
+    %[1]s fix --exit-code $code -- $history[1]
+end
+`
+)
+
+var widgets = map[string]string{
+	run.BashShell.String(): bashWidget,
+	run.ZshShell.String():  zshWidget,
+	run.FishShell.String(): fishWidget,
+}
+
+// runShellInit prints the ctrl+g widget and the fix hook of a shell, to be
+// evaluated in its rc file
+func runShellInit(args string, w io.Writer) int {
+	name := strings.TrimSpace(args)
+	widget, ok := widgets[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "usage: gogut %s bash|zsh|fish\n", shellInitSubcommand)
+		return exitUsage
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		executable = "gogut"
+	}
+
+	fmt.Fprintf(w, widget, run.GetShell(name).Quote(executable))
+
+	return exitOK
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// subcommands run instead of answering a prompt, when given as first argument
+const (
+	shellInitSubcommand = "shell-init"
+	fixSubcommand       = "fix"
+)
+
+// getSubcommand returns the subcommand asked by the arguments, only when they
+// cannot be a prompt: gogut fix needs a -- right after it, and gogut shell-init
+// a supported shell as single argument and no --, eg. gogut fix the ssh
+// permissions and gogut -- shell-init bash are prompts
+func getSubcommand(args []string, argsLenAtDash int) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	switch args[0] {
+	case fixSubcommand:
+		if argsLenAtDash == 1 {
+			return fixSubcommand
+		}
+	case shellInitSubcommand:
+		if _, ok := widgets[strings.Join(args[1:], " ")]; ok && argsLenAtDash < 0 {
+			return shellInitSubcommand
+		}
+	}
+
+	return ""
+}
+
+// RunSubcommand runs the subcommand given as first argument and returns the exit code
+func RunSubcommand(input *UIInput) int {
+	switch input.GetSubcommand() {
+	case shellInitSubcommand:
+		return runShellInit(input.GetArgs(), os.Stdout)
+	case fixSubcommand:
+		return runFix(input)
+	default:
+		fmt.Fprintf(os.Stderr, "error: unknown subcommand %q\n", input.GetSubcommand())
+		return exitUsage
+	}
+}
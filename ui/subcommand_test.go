@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSubcommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		argsLenAtDash int
+		expected      string
+	}{
+		{
+			name:          "no arguments",
+			args:          nil,
+			argsLenAtDash: -1,
+			expected:      "",
+		},
+		{
+			name:          "prompt",
+			args:          []string{"list", "files"},
+			argsLenAtDash: -1,
+			expected:      "",
+		},
+		{
+			name:          "fix with dash",
+			args:          []string{"fix", "gti", "status"},
+			argsLenAtDash: 1,
+			expected:      fixSubcommand,
+		},
+		{
+			name:          "fix without dash",
+			args:          []string{"fix", "the", "ssh", "permissions"},
+			argsLenAtDash: -1,
+			expected:      "",
+		},
+		{
+			name:          "fix after dash",
+			args:          []string{"fix", "gti", "status"},
+			argsLenAtDash: 0,
+			expected:      "",
+		},
+		{
+			name:          "fix with dash later",
+			args:          []string{"fix", "the", "build", "make"},
+			argsLenAtDash: 3,
+			expected:      "",
+		},
+		{
+			name:          "shell-init",
+			args:          []string{"shell-init", "bash"},
+			argsLenAtDash: -1,
+			expected:      shellInitSubcommand,
+		},
+		{
+			name:          "shell-init after dash",
+			args:          []string{"shell-init", "bash"},
+			argsLenAtDash: 0,
+			expected:      "",
+		},
+		{
+			name:          "shell-init with dash",
+			args:          []string{"shell-init", "bash"},
+			argsLenAtDash: 1,
+			expected:      "",
+		},
+		{
+			name:          "shell-init unknown shell",
+			args:          []string{"shell-init", "powershell"},
+			argsLenAtDash: -1,
+			expected:      "",
+		},
+		{
+			name:          "shell-init without shell",
+			args:          []string{"shell-init"},
+			argsLenAtDash: -1,
+			expected:      "",
+		},
+		{
+			name:          "shell-init prompt",
+			args:          []string{"shell-init", "for", "bash"},
+			argsLenAtDash: -1,
+			expected:      "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, getSubcommand(test.args, test.argsLenAtDash))
+		})
+	}
+}
+
+func TestRunShellInit(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     string
+		expected int
+		contains []string
+	}{
+		{
+			name:     "bash",
+			args:     "bash",
+			expected: exitOK,
+			contains: []string{"READLINE_LINE", `bind -x '"\C-g": _gogut_widget'`, "gogut_fix() {"},
+		},
+		{
+			name:     "zsh",
+			args:     " zsh ",
+			expected: exitOK,
+			contains: []string{"zle -N _gogut_widget", "bindkey '^G' _gogut_widget", "gogut_fix() {"},
+		},
+		{
+			name:     "fish",
+			args:     "fish",
+			expected: exitOK,
+			contains: []string{"function _gogut_widget", `bind \cg _gogut_widget`, "function gogut_fix"},
+		},
+		{
+			name:     "unknown shell",
+			args:     "powershell",
+			expected: exitUsage,
+		},
+		{
+			name:     "no shell",
+			args:     "",
+			expected: exitUsage,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var output bytes.Buffer
+
+			assert.Equal(t, test.expected, runShellInit(test.args, &output))
+			if test.expected != exitOK {
+				assert.Empty(t, output.String())
+			}
+			for _, expected := range test.contains {
+				assert.Contains(t, output.String(), expected)
+			}
+			assert.NotContains(t, output.String(), "%!")
+		})
+	}
+}
@@ -1,9 +1,12 @@
 package ui
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/bmichalkiewicz/gogut/ai"
 	"github.com/bmichalkiewicz/gogut/config"
@@ -17,17 +20,34 @@ import (
 )
 
 type UIState struct {
-	error       error
-	runMode     RunMode
-	promptMode  PromptMode
-	configuring bool
-	querying    bool
-	confirming  bool
-	executing   bool
-	args        string
-	pipe        string
-	buffer      string
-	command     string
+	error        error
+	subcommand   string
+	runMode      RunMode
+	promptMode   PromptMode
+	configuring  bool
+	querying     bool
+	confirming   bool
+	dangerous    bool
+	editing      bool
+	executing    bool
+	searching    bool
+	fixing       bool
+	attempts     int
+	failed       *failedCommand
+	exitCode     int
+	status       int
+	args         string
+	pipe         string
+	sampling     ai.Sampling
+	resume       bool
+	noCache      bool
+	dryRun       bool
+	yes          bool
+	buffer       string
+	command      string
+	confirmation string
+	prompt       string
+	commands     []history.Command
 }
 
 type UISize struct {
@@ -46,15 +66,21 @@ type UI struct {
 	dimensions UISize
 	components UIModels
 
-	config  *config.Config
-	engine  *ai.Engine
-	history *history.History
+	config    *config.Config
+	engine    *ai.Engine
+	history   *history.History
+	histories map[PromptMode]*history.History
+	search    *history.Search
+	commands  *history.CommandLog
+	detector  *run.Detector
+	policy    *run.Policy
 }
 
 func NewUI(input *UIInput) *UI {
 	return &UI{
 		state: UIState{
 			error:       nil,
+			subcommand:  input.GetSubcommand(),
 			runMode:     input.GetRunMode(),
 			promptMode:  input.GetPromptMode(),
 			configuring: false,
@@ -63,6 +89,13 @@ func NewUI(input *UIInput) *UI {
 			executing:   false,
 			args:        input.GetArgs(),
 			pipe:        input.GetPipe(),
+			sampling:    input.GetSampling(),
+			resume:      input.IsResume(),
+			noCache:     input.IsNoCache(),
+			dryRun:      input.IsDryRun(),
+			yes:         input.IsYes(),
+			exitCode:    input.GetExitCode(),
+			status:      exitError,
 			buffer:      "",
 			command:     "",
 		},
@@ -79,6 +112,11 @@ func NewUI(input *UIInput) *UI {
 			spinner: NewSpinner(),
 		},
 		history: history.NewHistory(),
+		histories: map[PromptMode]*history.History{
+			ExecPromptMode:    history.NewHistory(),
+			ChatPromptMode:    history.NewHistory(),
+			ExplainPromptMode: history.NewHistory(),
+		},
 	}
 }
 
@@ -86,7 +124,7 @@ func (u *UI) Init() tea.Cmd {
 	conf, err := config.NewConfig(facts.GetConfigFile())
 
 	if err != nil {
-		if errors.Is(err, config.ConfigFileNotfoundError{}) {
+		if errors.Is(err, config.ConfigFileNotfoundError{}) && u.state.subcommand == "" {
 			if u.state.runMode == ReplMode {
 				return tea.Sequence(
 					tea.ClearScreen,
@@ -103,7 +141,9 @@ func (u *UI) Init() tea.Cmd {
 		}
 	}
 
-	if u.state.runMode == ReplMode {
+	if u.state.subcommand == fixSubcommand {
+		return u.startFixCli(conf)
+	} else if u.state.runMode == ReplMode {
 		return u.startRepl(conf)
 	} else {
 		return u.startCli(conf)
@@ -137,9 +177,28 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		)
 	// keyboard
 	case tea.KeyMsg:
+		if u.state.searching {
+			return u, u.updateSearch(msg)
+		}
+		if u.state.editing {
+			return u, u.updateEdit(msg)
+		}
+		if u.state.fixing {
+			return u, u.updateFix(msg)
+		}
+		if u.state.confirming && u.state.dangerous && msg.Type != tea.KeyCtrlC {
+			return u, u.updateDangerousConfirmation(msg)
+		}
 		switch msg.Type {
-		// quit
+		// interrupt or quit
 		case tea.KeyCtrlC:
+			if u.state.querying && u.engine != nil {
+				u.engine.Interrupt()
+				return u, nil
+			}
+			if u.state.confirming && u.state.runMode == ReplMode {
+				return u, u.cancelConfirmation()
+			}
 			return u, tea.Quit
 		// history
 		case tea.KeyUp, tea.KeyDown:
@@ -162,16 +221,11 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// switch mode
 		case tea.KeyTab:
 			if !u.state.querying && !u.state.confirming {
-				if u.state.promptMode == ChatPromptMode {
-					u.state.promptMode = ExecPromptMode
-					u.components.prompt.SetMode(ExecPromptMode)
-					u.engine.SetMode(ai.ExecEngineMode)
-				} else {
-					u.state.promptMode = ChatPromptMode
-					u.components.prompt.SetMode(ChatPromptMode)
-					u.engine.SetMode(ai.ChatEngineMode)
-				}
-				u.engine.Reset()
+				u.state.promptMode = getNextPromptMode(u.state.promptMode)
+				u.components.prompt.SetMode(u.state.promptMode)
+				u.engine.SetMode(getEngineMode(u.state.promptMode))
+				// each mode keeps its own conversation, a resumed chat included
+				u.history = u.histories[u.state.promptMode]
 				u.components.prompt, promptCmd = u.components.prompt.Update(msg)
 				cmds = append(
 					cmds,
@@ -190,9 +244,22 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			if !u.state.querying && !u.state.confirming {
 				input := u.components.prompt.GetValue()
+				if isReplCommand(input) {
+					inputPrint := u.components.prompt.AsString()
+					historyCmd := u.addHistory(input)
+					u.components.prompt.SetValue("")
+					u.components.prompt, promptCmd = u.components.prompt.Update(msg)
+					return u, tea.Sequence(
+						promptCmd,
+						tea.Println(inputPrint),
+						historyCmd,
+						u.runReplCommand(input),
+						textinput.Blink,
+					)
+				}
 				if input != "" {
 					inputPrint := u.components.prompt.AsString()
-					u.history.Add(input)
+					historyCmd := u.addHistory(input)
 					u.components.prompt.SetValue("")
 					u.components.prompt.Blur()
 					u.components.prompt, promptCmd = u.components.prompt.Update(msg)
@@ -201,14 +268,25 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							cmds,
 							promptCmd,
 							tea.Println(inputPrint),
+							historyCmd,
 							u.startChatStream(input),
 							u.awaitChatStream(),
 						)
+					} else if u.state.promptMode == ExplainPromptMode {
+						cmds = append(
+							cmds,
+							promptCmd,
+							tea.Println(inputPrint),
+							historyCmd,
+							u.startExplain(input),
+							u.components.spinner.Tick,
+						)
 					} else {
 						cmds = append(
 							cmds,
 							promptCmd,
 							tea.Println(inputPrint),
+							historyCmd,
 							u.startExec(input),
 							u.components.spinner.Tick,
 						)
@@ -240,8 +318,14 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				)
 			}
 
-		// reset
+		// history search
 		case tea.KeyCtrlR:
+			if !u.state.configuring && !u.state.querying && !u.state.confirming && !u.state.executing {
+				u.startSearch()
+			}
+
+		// reset
+		case tea.KeyCtrlX:
 			if !u.state.configuring && !u.state.querying && !u.state.confirming {
 				u.history.Reset()
 				u.engine.Reset()
@@ -272,38 +356,15 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		default:
 			if u.state.confirming {
-				if strings.ToLower(msg.String()) == "y" {
-					u.state.confirming = false
-					u.state.executing = true
-					u.state.buffer = ""
-					u.components.prompt.SetValue("")
-					return u, tea.Sequence(
-						promptCmd,
-						u.execCommand(u.state.command),
-					)
-				} else {
-					u.state.confirming = false
-					u.state.executing = false
-					u.state.buffer = ""
-					u.components.prompt, promptCmd = u.components.prompt.Update(msg)
-					u.components.prompt.SetValue("")
-					u.components.prompt.Focus()
-					if u.state.runMode == ReplMode {
-						cmds = append(
-							cmds,
-							promptCmd,
-							tea.Println(fmt.Sprintf("\n%s\n", u.components.renderer.RenderWarning("[cancel]"))),
-							textinput.Blink,
-						)
-					} else {
-						return u, tea.Sequence(
-							promptCmd,
-							tea.Println(fmt.Sprintf("\n%s\n", u.components.renderer.RenderWarning("[cancel]"))),
-							tea.Quit,
-						)
-					}
+				switch strings.ToLower(msg.String()) {
+				case "y":
+					return u, u.acceptConfirmation()
+				case editKey:
+					return u, u.startEdit()
+				case editorKey:
+					return u, u.startEditor()
 				}
-				u.state.command = ""
+				return u, u.rejectConfirmation()
 			} else {
 				u.components.prompt.Focus()
 				u.components.prompt, promptCmd = u.components.prompt.Update(msg)
@@ -314,17 +375,52 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				)
 			}
 		}
+	// engine explain feedback
+	case ai.EngineExplainOutput:
+		output := u.renderExplanation(msg)
+		u.components.prompt.Focus()
+		if u.state.runMode == CliMode {
+			return u, tea.Sequence(
+				tea.Println(output),
+				tea.Quit,
+			)
+		}
+		u.components.prompt, promptCmd = u.components.prompt.Update(msg)
+		return u, tea.Sequence(
+			promptCmd,
+			textinput.Blink,
+			tea.Println(output),
+		)
 	// engine exec feedback
 	case ai.EngineExecOutput:
 		var output string
-		if msg.IsExecutable() {
-			u.state.confirming = true
-			u.state.command = msg.GetCommand()
-			output = u.components.renderer.RenderContent(fmt.Sprintf("`%s`", u.state.command))
-			output += fmt.Sprintf("  %s\n\n  confirm execution? [y/N]", u.components.renderer.RenderHelp(msg.GetExplanation()))
-			u.components.prompt.Blur()
+		output += u.renderBackend(msg.GetBackend())
+		if msg.IsCached() {
+			output += u.components.renderer.RenderHelp("  [cached]") + "\n"
+		}
+		output += u.renderWarnings(msg.GetWarnings())
+		for _, call := range msg.GetToolCalls() {
+			output += u.renderToolCall(call)
+		}
+		if msg.IsExecutable() && u.state.dryRun {
+			output += u.renderDryRun(msg.GetCommand(), msg.GetExplanation())
+			u.components.prompt.Focus()
+			if u.state.runMode == CliMode {
+				return u, tea.Sequence(
+					tea.Println(output),
+					tea.Quit,
+				)
+			}
+		} else if msg.IsExecutable() && u.isAllowed(msg.GetCommand()) {
+			allowed, execCmd := u.allowCommand(msg.GetCommand(), msg.GetExplanation())
+			return u, tea.Sequence(
+				tea.Println(output+allowed),
+				execCmd,
+			)
+		} else if msg.IsExecutable() {
+			output += u.confirmCommand(msg.GetCommand(), msg.GetExplanation())
 		} else {
-			output = u.components.renderer.RenderContent(msg.GetExplanation())
+			output += u.components.renderer.RenderContent(msg.GetExplanation())
 			u.components.prompt.Focus()
 			if u.state.runMode == CliMode {
 				return u, tea.Sequence(
@@ -339,10 +435,16 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			textinput.Blink,
 			tea.Println(output),
 		)
+	// editor feedback
+	case editedCommand:
+		return u, u.finishEditor(msg)
 	// engine chat stream feedback
 	case ai.EngineChatStreamOutput:
 		if msg.IsLast() {
-			output := u.components.renderer.RenderContent(u.state.buffer)
+			output := u.renderBackend(msg.GetBackend()) + u.renderWarnings(msg.GetWarnings()) + u.components.renderer.RenderContent(u.state.buffer)
+			if !msg.IsInterrupt() {
+				output += u.saveSession()
+			}
 			u.state.buffer = ""
 			u.components.prompt.Focus()
 			if u.state.runMode == CliMode {
@@ -367,6 +469,12 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		output := u.components.renderer.RenderSuccess(fmt.Sprintf("\n%s\n", msg.GetSuccessMessage()))
 		if msg.HasError() {
 			output = u.components.renderer.RenderError(fmt.Sprintf("\n%s\n", msg.GetErrorMessage()))
+			if offer := u.offerFix(); offer != "" {
+				output += offer
+				if u.state.fixing {
+					return u, tea.Println(output)
+				}
+			}
 		}
 		if u.state.runMode == CliMode {
 			return u, tea.Sequence(
@@ -382,6 +490,9 @@ func (u *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	// errors
 	case error:
+		if errors.Is(msg, context.Canceled) {
+			return u, u.finishInterrupt()
+		}
 		u.state.error = msg
 		return u, nil
 	}
@@ -402,10 +513,22 @@ func (u *UI) View() string {
 		)
 	}
 
+	if u.state.searching {
+		return u.renderSearch()
+	}
+
+	if u.state.fixing {
+		return ""
+	}
+
 	if !u.state.querying && !u.state.confirming && !u.state.executing {
 		return u.components.prompt.View()
 	}
 
+	if u.state.confirming && u.state.dangerous {
+		return u.renderDangerousConfirmation()
+	}
+
 	if u.state.promptMode == ChatPromptMode {
 		return u.components.renderer.RenderContent(u.state.buffer)
 	} else {
@@ -425,6 +548,9 @@ func (u *UI) startRepl(config *config.Config) tea.Cmd {
 	return tea.Sequence(
 		tea.ClearScreen,
 		tea.Println(u.components.renderer.RenderContent(u.components.renderer.RenderHelpMessage())),
+		u.loadHistories(config),
+		u.loadSafetyRules(config),
+		u.loadPolicy(config),
 		textinput.Blink,
 		func() tea.Msg {
 			u.config = config
@@ -432,19 +558,15 @@ func (u *UI) startRepl(config *config.Config) tea.Cmd {
 			if u.state.promptMode == DefaultPromptMode {
 				u.state.promptMode = GetPromptModeFromString(config.GetUserConfig().GetDefaultPromptMode())
 			}
+			u.history = u.histories[u.state.promptMode]
 
-			engineMode := ai.ExecEngineMode
-			if u.state.promptMode == ChatPromptMode {
-				engineMode = ai.ChatEngineMode
-			}
-
-			engine, err := ai.NewEngine(engineMode, config)
+			engine, err := u.newEngine(getEngineMode(u.state.promptMode), config)
 			if err != nil {
 				return err
 			}
 
-			if u.state.pipe != "" {
-				engine.SetPipe(u.state.pipe)
+			if err := u.resumeSession(engine); err != nil {
+				return err
 			}
 
 			u.engine = engine
@@ -464,19 +586,15 @@ func (u *UI) startCli(config *config.Config) tea.Cmd {
 		u.state.promptMode = GetPromptModeFromString(config.GetUserConfig().GetDefaultPromptMode())
 	}
 
-	engineMode := ai.ExecEngineMode
-	if u.state.promptMode == ChatPromptMode {
-		engineMode = ai.ChatEngineMode
-	}
-
-	engine, err := ai.NewEngine(engineMode, config)
+	engine, err := u.newEngine(getEngineMode(u.state.promptMode), config)
 	if err != nil {
 		u.state.error = err
 		return nil
 	}
 
-	if u.state.pipe != "" {
-		engine.SetPipe(u.state.pipe)
+	if err := u.resumeSession(engine); err != nil {
+		u.state.error = err
+		return nil
 	}
 
 	u.engine = engine
@@ -485,11 +603,18 @@ func (u *UI) startCli(config *config.Config) tea.Cmd {
 	u.state.buffer = ""
 	u.state.command = ""
 
+	u.loadHistories(config)
+	u.history = u.histories[u.state.promptMode]
+	u.history.Add(u.state.args)
+	u.state.prompt = u.state.args
+
 	if u.state.promptMode == ExecPromptMode {
 		return tea.Batch(
+			u.loadSafetyRules(config),
+			u.loadPolicy(config),
 			u.components.spinner.Tick,
 			func() tea.Msg {
-				output, err := u.engine.ExecCompletion(u.state.args)
+				output, err := u.engine.ExecCompletion(context.Background(), u.state.args)
 				u.state.querying = false
 				if err != nil {
 					return err
@@ -498,6 +623,12 @@ func (u *UI) startCli(config *config.Config) tea.Cmd {
 				return *output
 			},
 		)
+	} else if u.state.promptMode == ExplainPromptMode {
+		return tea.Batch(
+			u.loadSafetyRules(config),
+			u.startExplain(u.state.args),
+			u.components.spinner.Tick,
+		)
 	} else {
 		return tea.Batch(
 			u.startChatStream(u.state.args),
@@ -531,21 +662,26 @@ func (u *UI) finishConfig(key string) tea.Cmd {
 	}
 
 	u.config = config
-	engine, err := ai.NewEngine(ai.ExecEngineMode, config)
+	engine, err := u.newEngine(ai.ExecEngineMode, config)
 	if err != nil {
 		u.state.error = err
 		return nil
 	}
 
-	if u.state.pipe != "" {
-		engine.SetPipe(u.state.pipe)
-	}
-
 	u.engine = engine
 
+	historiesCmd := u.loadHistories(config)
+	safetyCmd := u.loadSafetyRules(config)
+	policyCmd := u.loadPolicy(config)
+	u.history = u.histories[ExecPromptMode]
+	u.state.prompt = u.state.args
+
 	if u.state.runMode == ReplMode {
 		return tea.Sequence(
 			tea.ClearScreen,
+			historiesCmd,
+			safetyCmd,
+			policyCmd,
 			tea.Println(u.components.renderer.RenderSuccess("\n[settings ok]\n")),
 			textinput.Blink,
 			func() tea.Msg {
@@ -563,9 +699,11 @@ func (u *UI) finishConfig(key string) tea.Cmd {
 			u.state.buffer = ""
 			return tea.Sequence(
 				tea.Println(u.components.renderer.RenderSuccess("\n[settings ok]")),
+				safetyCmd,
+				policyCmd,
 				u.components.spinner.Tick,
 				func() tea.Msg {
-					output, err := u.engine.ExecCompletion(u.state.args)
+					output, err := u.engine.ExecCompletion(context.Background(), u.state.args)
 					u.state.querying = false
 					if err != nil {
 						return err
@@ -574,6 +712,15 @@ func (u *UI) finishConfig(key string) tea.Cmd {
 					return *output
 				},
 			)
+		} else if u.state.promptMode == ExplainPromptMode {
+			u.state.configuring = false
+			u.engine.SetMode(ai.ExplainEngineMode)
+			return tea.Sequence(
+				tea.Println(u.components.renderer.RenderSuccess("\n[settings ok]")),
+				safetyCmd,
+				u.components.spinner.Tick,
+				u.startExplain(u.state.args),
+			)
 		} else {
 			return tea.Batch(
 				u.startChatStream(u.state.args),
@@ -583,14 +730,216 @@ func (u *UI) finishConfig(key string) tea.Cmd {
 	}
 }
 
+func (u *UI) newEngine(mode ai.EngineMode, config *config.Config) (*ai.Engine, error) {
+	return newEngine(mode, config, u.state.pipe, u.state.sampling, u.state.noCache)
+}
+
+// newEngine builds the engine answering the prompts, with the piped data, the
+// sampling flags and the cache unless disabled, for the interface and the
+// headless mode alike
+func newEngine(mode ai.EngineMode, config *config.Config, pipe string, sampling ai.Sampling, noCache bool) (*ai.Engine, error) {
+	engine, err := ai.NewEngine(mode, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if pipe != "" {
+		engine.SetPipe(pipe)
+	}
+
+	engine.SetSampling(sampling)
+
+	if !noCache && config.GetCacheConfig().IsEnabled() {
+		engine.SetCache(ai.NewCache(facts.GetCacheDir(), config.GetCacheConfig().GetTTL()))
+	}
+
+	return engine, nil
+}
+
+// loadHistories loads the history of every prompt mode from disk, falling
+// back on an in-memory history when a file cannot be read
+func (u *UI) loadHistories(config *config.Config) tea.Cmd {
+	var output string
+	for _, mode := range promptModes {
+		h, err := history.NewFileHistory(facts.GetHistoryFile(mode.String()), config.GetHistoryConfig().GetMaxSize())
+		if err != nil {
+			output += u.components.renderer.RenderWarning(fmt.Sprintf("  [history] %s", err)) + "\n"
+			continue
+		}
+		u.histories[mode] = h
+	}
+	u.commands = history.NewCommandLog(facts.GetCommandsFile(), config.GetHistoryConfig().GetMaxSize())
+
+	if output == "" {
+		return nil
+	}
+
+	return tea.Println(output)
+}
+
+// recordCommand logs an executed command, logging is best effort and never
+// gets in the way of the command result
+func (u *UI) recordCommand(command history.Command) {
+	if u.commands == nil {
+		return
+	}
+
+	_ = u.commands.Add(command)
+}
+
+func (u *UI) addHistory(input string) tea.Cmd {
+	if err := u.history.Add(input).GetError(); err != nil {
+		return tea.Println(u.components.renderer.RenderWarning(fmt.Sprintf("  [history] %s", err)))
+	}
+
+	return nil
+}
+
+// resumeSession restores the last chat session of the working directory when
+// asked to with --resume
+func (u *UI) resumeSession(engine *ai.Engine) error {
+	if !u.state.resume {
+		return nil
+	}
+
+	session, err := ai.LoadSession(getSessionFile())
+	if err != nil {
+		return fmt.Errorf("error loading session: %w", err)
+	}
+
+	engine.SetSession(session)
+
+	return nil
+}
+
+// saveSession persists the chat session so it can be resumed, failures are only reported
+func (u *UI) saveSession() string {
+	if err := ai.SaveSession(getSessionFile(), u.engine.GetSession()); err != nil {
+		return u.components.renderer.RenderWarning(fmt.Sprintf("  [session] %s", err)) + "\n"
+	}
+
+	return ""
+}
+
+// getSessionFile returns the session file of the working directory
+func getSessionFile() string {
+	cwd, _ := os.Getwd()
+
+	return facts.GetSessionFile(cwd)
+}
+
+func (u *UI) finishInterrupt() tea.Cmd {
+	u.state.status = exitInterrupted
+	u.state.querying = false
+	u.state.confirming = false
+	u.state.buffer = ""
+	u.state.command = ""
+	u.components.prompt.Focus()
+
+	output := fmt.Sprintf("\n%s\n", u.components.renderer.RenderWarning("[interrupt]"))
+	if u.state.runMode == CliMode {
+		return tea.Sequence(
+			tea.Println(output),
+			tea.Quit,
+		)
+	}
+
+	return tea.Sequence(
+		tea.Println(output),
+		textinput.Blink,
+	)
+}
+
+func (u *UI) resetConfirmation() {
+	u.state.confirming = false
+	u.state.dangerous = false
+	u.state.confirmation = ""
+}
+
+// acceptConfirmation runs the confirmed command
+func (u *UI) acceptConfirmation() tea.Cmd {
+	u.resetConfirmation()
+	u.state.executing = true
+	u.state.buffer = ""
+	u.components.prompt.SetValue("")
+
+	return u.execCommand(u.state.command)
+}
+
+// rejectConfirmation cancels the command, in cli mode gogut exits
+func (u *UI) rejectConfirmation() tea.Cmd {
+	cancelCmd := u.cancelConfirmation()
+	if u.state.runMode == CliMode {
+		return tea.Sequence(
+			cancelCmd,
+			tea.Quit,
+		)
+	}
+
+	return cancelCmd
+}
+
+func (u *UI) cancelConfirmation() tea.Cmd {
+	u.resetConfirmation()
+	u.state.editing = false
+	u.state.executing = false
+	u.state.buffer = ""
+	u.state.command = ""
+	u.components.prompt.SetValue("")
+	u.components.prompt.Focus()
+
+	return tea.Sequence(
+		tea.Println(fmt.Sprintf("\n%s\n", u.components.renderer.RenderWarning("[cancel]"))),
+		textinput.Blink,
+	)
+}
+
+// getShell returns the shell executing the commands, from the configuration or $SHELL
+func (u *UI) getShell() run.Shell {
+	if u.config == nil {
+		return run.GetShell(facts.GetShell())
+	}
+
+	return run.GetShell(u.config.GetSystemConfig().GetShell())
+}
+
+// renderBackend reports which backend answered, when fallbacks make it ambiguous
+func (u *UI) renderBackend(backend string) string {
+	if backend == "" || len(u.config.GetAIConfig().GetFallbacks()) == 0 {
+		return ""
+	}
+
+	return u.components.renderer.RenderHelp(fmt.Sprintf("  [via %s]", backend)) + "\n"
+}
+
+func (u *UI) renderWarnings(warnings []string) string {
+	var output string
+	for _, warning := range warnings {
+		output += u.components.renderer.RenderWarning(fmt.Sprintf("  [context] %s", warning)) + "\n"
+	}
+
+	return output
+}
+
+func (u *UI) renderToolCall(call ai.EngineToolCall) string {
+	line := fmt.Sprintf("  [tool] %s %s", call.GetName(), call.GetArguments())
+	if call.HasFailed() {
+		return u.components.renderer.RenderWarning(fmt.Sprintf("%s: %s", line, call.GetOutput())) + "\n"
+	}
+
+	return u.components.renderer.RenderHelp(line) + "\n"
+}
+
 func (u *UI) startExec(input string) tea.Cmd {
 	return func() tea.Msg {
 		u.state.querying = true
 		u.state.confirming = false
 		u.state.buffer = ""
 		u.state.command = ""
+		u.state.prompt = input
+		u.state.attempts = 0
 
-		output, err := u.engine.ExecCompletion(input)
+		output, err := u.engine.ExecCompletion(context.Background(), input)
 		u.state.querying = false
 		if err != nil {
 			return err
@@ -608,7 +957,7 @@ func (u *UI) startChatStream(input string) tea.Cmd {
 		u.state.buffer = ""
 		u.state.command = ""
 
-		err := u.engine.ChatStreamCompletion(input)
+		err := u.engine.ChatStreamCompletion(context.Background(), input)
 		if err != nil {
 			return err
 		}
@@ -632,12 +981,32 @@ func (u *UI) execCommand(input string) tea.Cmd {
 	u.state.confirming = false
 	u.state.executing = true
 
-	c := run.PrepareInteractiveCommand(input)
+	capture := run.NewCapture(maxCapturedOutput)
+	c := run.PrepareInteractiveCommand(u.getShell(), input)
+	run.TeeOutput(c.Cmd, capture)
+	cwd, _ := os.Getwd()
+	start := time.Now()
 
-	return tea.ExecProcess(c, func(error error) tea.Msg {
+	return tea.Exec(c, func(error error) tea.Msg {
 		u.state.executing = false
 		u.state.command = ""
 
+		u.state.failed = nil
+		if error != nil {
+			u.state.failed = &failedCommand{
+				command:  input,
+				exitCode: run.GetExitCode(error),
+				output:   capture.String(),
+			}
+		}
+
+		u.state.status = run.GetExitCode(error)
+		if u.state.status < 0 {
+			u.state.status = exitError
+		}
+
+		u.recordCommand(history.NewCommand(u.state.prompt, input, cwd, start, run.GetExitCode(error), time.Since(start)))
+
 		return run.NewRunOutput(error, "[error]", "[ok]")
 	})
 }
@@ -647,13 +1016,13 @@ func (u *UI) editSettings() tea.Cmd {
 	u.state.confirming = false
 	u.state.executing = true
 
-	c := run.PrepareEditSettingsCommand(fmt.Sprintf(
-		"%s %s",
+	c := run.PrepareEditSettingsCommand(
+		u.getShell(),
 		u.config.GetSystemConfig().GetEditor(),
 		u.config.GetSystemConfig().GetConfigFile(),
-	))
+	)
 
-	return tea.ExecProcess(c, func(error error) tea.Msg {
+	return tea.Exec(c, func(error error) tea.Msg {
 		u.state.executing = false
 		u.state.command = ""
 
@@ -667,15 +1036,18 @@ func (u *UI) editSettings() tea.Cmd {
 		}
 
 		u.config = config
-		engine, error := ai.NewEngine(ai.ExecEngineMode, config)
-		if u.state.pipe != "" {
-			engine.SetPipe(u.state.pipe)
-		}
+		engine, error := u.newEngine(ai.ExecEngineMode, config)
 		if error != nil {
 			return run.NewRunOutput(error, "[settings error]", "")
 		}
 		u.engine = engine
 
+		detector, errs := newDetector(config)
+		u.detector = detector
+		if len(errs) > 0 {
+			return run.NewRunOutput(errs[0], "[settings error]", "")
+		}
+
 		return run.NewRunOutput(nil, "", "[settings ok]")
 	})
 }